@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yuyudeqiu/chronicle/internal/config"
+	"github.com/yuyudeqiu/chronicle/internal/service"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup <file>",
+	Short: "Write a consistent copy of the local SQLite database (safe while the server runs)",
+	Args:  cobra.ExactArgs(1),
+	// backups always read the local database, even when a remote server is configured,
+	// and copy it as-is so one can be taken before running `chronicle migrate up`
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		initConfig(cmd)
+
+		db, err := service.OpenDB(config.GetDatabase())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		service.DB = db
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := service.Backup(args[0]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		version, err := service.VerifyBackup(args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if jsonOutput {
+			printJSON(map[string]interface{}{"file": args[0], "schema_version": version, "status": "ok"})
+		} else {
+			fmt.Printf("Backup written to %s (schema version %d)\n", args[0], version)
+		}
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <file>",
+	Short: "Replace the local SQLite database with a backup",
+	Long: `Replace the local SQLite database with a backup.
+
+The backup is integrity-checked first, the current database is kept next to it
+as app.db.before-restore-<time>, and pending migrations are applied to the
+restored copy. Stop the server before restoring.`,
+	Args: cobra.ExactArgs(1),
+	// the database must not be opened before it is replaced
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		initConfig(cmd)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		driver, dbPath := config.GetDatabase()
+		if driver != service.DriverSQLite {
+			fmt.Printf("Error: %v\n", service.ErrBackupUnsupported)
+			os.Exit(1)
+		}
+
+		previous, err := service.Restore(args[0], dbPath)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		// restoring is explicit, so bring the restored copy up to date right away
+		service.InitDB(driver, dbPath, true)
+
+		if jsonOutput {
+			printJSON(map[string]interface{}{"file": args[0], "database": dbPath, "previous": previous, "status": "restored"})
+			return
+		}
+		fmt.Printf("Restored %s from %s\n", dbPath, args[0])
+		if previous != "" {
+			fmt.Printf("Previous database saved to %s\n", previous)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd, restoreCmd)
+}
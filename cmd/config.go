@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yuyudeqiu/chronicle/internal/config"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show or change configuration",
+	Long: `Show or change configuration stored in the config file.
+
+Values are resolved in this order: command line flag > environment variable >
+config file (active profile, then top level) > default.`,
+	// config commands must work without a database, and before the config file or profile exists
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		err := loadConfig(cmd)
+		if err != nil && !errors.Is(err, config.ErrProfileNotFound) && !errors.Is(err, os.ErrNotExist) {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Get the effective value of a config key",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		value, source := config.Get(args[0])
+		if source == "" {
+			fmt.Printf("Error: unknown config key %q\n", args[0])
+			os.Exit(1)
+		}
+
+		if jsonOutput {
+			printJSON(map[string]string{"key": args[0], "value": value, "source": source})
+		} else {
+			fmt.Println(value)
+		}
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Write a config key to the config file (into the active profile, if any)",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := config.Set(args[0], args[1]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if jsonOutput {
+			printJSON(map[string]string{"key": args[0], "value": args[1], "profile": config.ActiveProfile(), "status": "updated"})
+		} else {
+			fmt.Printf("Set %s = %s in %s", args[0], args[1], config.GetConfigPath())
+			if p := config.ActiveProfile(); p != "" && args[0] != "profile" {
+				fmt.Printf(" (profile %s)", p)
+			}
+			fmt.Println()
+		}
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all config keys with their effective values and sources",
+	Run: func(cmd *cobra.Command, args []string) {
+		type entry struct {
+			Key    string `json:"key"`
+			Value  string `json:"value"`
+			Source string `json:"source"`
+		}
+
+		var entries []entry
+		for _, key := range config.Keys() {
+			value, source := config.Get(key)
+			entries = append(entries, entry{Key: key, Value: value, Source: source})
+		}
+
+		if jsonOutput {
+			printJSON(map[string]interface{}{
+				"config_file": config.GetConfigPath(),
+				"profile":     config.ActiveProfile(),
+				"profiles":    config.ProfileNames(),
+				"settings":    entries,
+			})
+			return
+		}
+
+		fmt.Printf("Config file: %s\n", config.GetConfigPath())
+		if p := config.ActiveProfile(); p != "" {
+			fmt.Printf("Profile: %s\n", p)
+		}
+		fmt.Println()
+		for _, e := range entries {
+			fmt.Printf("  %-26s %-24s (%s)\n", e.Key, e.Value, e.Source)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configGetCmd, configSetCmd, configListCmd)
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yuyudeqiu/chronicle/internal/model"
+)
+
+var contextBudget int
+
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Show a compact snapshot of open work for agents",
+	Long: `Show a compact snapshot of open work, meant to be read by an agent in one call
+instead of list, get and summary.
+
+Sections come in priority order: overdue tasks, tasks in progress with their last
+worklog, todo tasks due in the next 7 days and today's worklogs. Items are left
+out from the end until the snapshot fits in about --budget tokens; each section
+still shows how many items it left out. The budget applies to the text output;
+--json prints the same snapshot in a larger structured form.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		snapshot, err := api.GetAgentContext(contextBudget, mustLocation())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if jsonOutput {
+			printJSON(snapshot)
+		} else {
+			fmt.Print(snapshot.Text())
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(contextCmd)
+	contextCmd.Flags().IntVarP(&contextBudget, "budget", "b", model.DefaultContextBudget, "Approximate size limit in tokens")
+}
@@ -0,0 +1,325 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yuyudeqiu/chronicle/internal/config"
+	"github.com/yuyudeqiu/chronicle/internal/exporter"
+	"github.com/yuyudeqiu/chronicle/internal/model"
+)
+
+var (
+	exportFormat string
+	exportOut    string
+	exportTodo   bool
+	exportDate   string
+	exportIDs    []string
+	exportZip    bool
+	importMode   string
+	csvOptions   model.CSVExportReq
+	csvMapping   string
+	listImport   model.TaskListImportReq
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [format]",
+	Short: "Export tasks and worklogs (default: full JSON dump)",
+	Long: `Export tasks and worklogs.
+
+  json  the whole database (tasks, worklogs, archive and status history) as a
+        versioned dump that keeps all IDs and timestamps and can be loaded into
+        another instance with "chronicle import"
+  csv   tasks (filtered like "chronicle list") or, with --type worklogs, the
+        worklogs of a date range, for spreadsheets
+  ics   deadlines of open tasks as an iCalendar file, the same as the
+        /api/v1/calendar.ics feed
+
+Task formats render the tasks selected by --date, --from/--to (completed on a
+day or in a range), -c and --ids, all tasks if none is given. Each writes one
+document, or with --zip a zip archive of one file per task:
+`,
+	Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: append([]string{"csv", "ics"}, exporter.FormatNames()...),
+	Run: func(cmd *cobra.Command, args []string) {
+		format := exportFormat
+		if len(args) > 0 {
+			format = args[0]
+		}
+
+		var data []byte
+		var summary string
+		filtered := exportDate != "" || csvOptions.From != "" || csvOptions.To != "" || csvOptions.Category != "" || len(exportIDs) > 0 || cmd.Flags().Changed("zip")
+
+		switch {
+		case format == "json" && !filtered:
+			dump, err := api.ExportDump()
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			if data, err = json.MarshalIndent(dump, "", "  "); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			data = append(data, '\n')
+			summary = fmt.Sprintf("%d tasks, %d worklogs and %d status changes", len(dump.Tasks), len(dump.Logs), len(dump.StatusChanges))
+		case format == "csv":
+			var err error
+			if data, err = api.ExportCSV(csvOptions, mustLocation()); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			summary = "CSV"
+		case format == "ics":
+			req := model.CalendarReq{Type: model.CalendarTypeEvent, Category: csvOptions.Category}
+			if exportTodo {
+				req.Type = model.CalendarTypeTodo
+			}
+			var err error
+			if data, err = api.ExportICS(req); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			summary = "calendar"
+		default:
+			f, ok := exporter.Lookup(format)
+			if !ok {
+				fmt.Printf("Error: unsupported format: %s (supported: csv, ics, %s)\n", format, strings.Join(exporter.FormatNames(), ", "))
+				os.Exit(1)
+			}
+			req := model.ExportReq{
+				Date:     exportDate,
+				From:     csvOptions.From,
+				To:       csvOptions.To,
+				Category: csvOptions.Category,
+				IDs:      strings.Join(exportIDs, ","),
+			}
+			asZip := f.Zip()
+			if cmd.Flags().Changed("zip") {
+				asZip = exportZip
+				req.Output = model.ExportOutputFile
+				if asZip {
+					req.Output = model.ExportOutputZip
+				}
+			}
+
+			var err error
+			if data, err = api.Export(format, req, mustLocation()); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			summary = format
+			// Archives are not meant for the terminal
+			if asZip && exportOut == "" {
+				exportOut = "chronicle-" + format + ".zip"
+			}
+		}
+
+		if exportOut == "" {
+			os.Stdout.Write(data)
+			return
+		}
+		if err := os.WriteFile(exportOut, data, 0644); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Exported %s to %s\n", summary, exportOut)
+	},
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import [json|csv|todotxt|taskwarrior|markdown|github|gitlab|jira] <file>",
+	Short: "Import a JSON dump, a CSV file, or tasks and issues from another tool",
+	Long: `Import a JSON dump created by "chronicle export", or a CSV file.
+
+A JSON dump is validated before anything is written, and imported in one
+transaction with its IDs and timestamps preserved. Modes:
+  merge    keep existing data; add new records and replace tasks whose
+           imported copy was updated more recently (default)
+  replace  delete all existing data first
+
+A CSV file is matched to fields by its header row (the columns written by
+"chronicle export csv"); use --map for other headers, e.g.
+  chronicle import csv sheet.csv --map title=Summary,category=Project
+Rows that cannot be imported are listed with their line numbers.
+
+Task lists from other tools:
+  todotxt      todo.txt: the first +project becomes the category, @contexts and
+               other projects tags, due:YYYY-MM-DD the deadline
+  taskwarrior  "task export" output: projects become categories, annotations
+               worklogs; tasks keep their UUID
+  markdown     "- [ ]" / "- [x]" checklists under headings, which become the
+               categories; nested checklist items become the task's targets
+Tasks without a project or heading get --category (default: default_category,
+or inbox).
+
+Issues exported as JSON from an issue tracker:
+  github       GET /repos/{owner}/{repo}/issues; comments from
+               /issues/comments may be added to the same array
+  gitlab       GET /projects/:id/issues; notes embedded as "notes"
+  jira         GET /rest/api/2/search (or api/3); comments included
+Repositories and projects become categories and comments worklogs. Each task
+records its issue (e.g. github:owner/repo#12) and links to it, so an issue
+imported again updates its task instead of adding another.
+
+Priorities, tags and labels are kept in the description. Importing the same
+file again only updates tasks the file records a newer change for, so it can be
+re-run safely; use --dry-run to see what would be imported first.`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		format, file := "json", args[0]
+		if len(args) == 2 {
+			format, file = args[0], args[1]
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		switch format {
+		case "json":
+			var dump model.Dump
+			if err := json.Unmarshal(data, &dump); err != nil {
+				fmt.Printf("Error: %s is not a valid JSON dump: %v\n", file, err)
+				os.Exit(1)
+			}
+			result, err := api.ImportDump(&dump, importMode)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			if jsonOutput {
+				printJSON(result)
+				return
+			}
+			fmt.Printf("Imported %s (%s mode)\n", file, result.Mode)
+			printImportResult(*result)
+		case "csv":
+			result, err := api.ImportCSV(data, csvOptions.Type, csvMapping, mustLocation())
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			if jsonOutput {
+				printJSON(result)
+				return
+			}
+			fmt.Printf("Imported %s\n", file)
+			printImportResult(result.ImportResult)
+			printRowErrors(result.Errors)
+		case model.ImportFormatTodoTxt, model.ImportFormatTaskwarrior, model.ImportFormatMarkdown,
+			model.ImportFormatGitHub, model.ImportFormatGitLab, model.ImportFormatJira:
+			req := listImport
+			if req.Category == "" {
+				req.Category = config.GetDefaultCategory()
+			}
+			result, err := api.ImportTaskList(data, format, req, mustLocation())
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			if jsonOutput {
+				printJSON(result)
+				return
+			}
+			printImportedTasks(result.Items, result.DryRun)
+			if result.DryRun {
+				fmt.Printf("Dry run of %s, nothing was imported\n", file)
+			} else {
+				fmt.Printf("Imported %s\n", file)
+			}
+			printImportResult(result.ImportResult)
+			printRowErrors(result.Errors)
+		default:
+			fmt.Printf("Error: unsupported format: %s (supported: json, csv, todotxt, taskwarrior, markdown, github, gitlab, jira)\n", format)
+			os.Exit(1)
+		}
+	},
+}
+
+func printImportResult(r model.ImportResult) {
+	for _, c := range []struct {
+		label  string
+		counts model.ImportCounts
+	}{
+		{"Tasks", r.Tasks},
+		{"Worklogs", r.Logs},
+		{"Status changes", r.StatusChanges},
+	} {
+		fmt.Printf("  %-15s created=%d, updated=%d, skipped=%d\n", c.label+":", c.counts.Created, c.counts.Updated, c.counts.Skipped)
+	}
+}
+
+// printImportedTasks lists the tasks read from a task list; after an import only
+// those that were written.
+func printImportedTasks(tasks []model.ImportedTask, dryRun bool) {
+	loc := mustLocation()
+	printed := false
+	for _, t := range tasks {
+		if !dryRun && t.Action == model.ImportActionSkip {
+			continue
+		}
+		line := fmt.Sprintf("  %-6s line %-4d [%s] %s / %s", t.Action, t.Line, t.Task.Status, t.Task.Category, t.Task.Title)
+		if t.Task.ExternalRef != "" {
+			line += "  " + t.Task.ExternalRef
+		}
+		if t.Task.Deadline != nil {
+			line += "  due " + t.Task.Deadline.In(loc).Format("2006-01-02")
+		}
+		if t.Logs > 0 {
+			line += fmt.Sprintf("  (%d worklogs)", t.Logs)
+		}
+		fmt.Println(line)
+		printed = true
+	}
+	if printed {
+		fmt.Println()
+	}
+}
+
+func printRowErrors(errs []model.ImportRowError) {
+	if len(errs) == 0 {
+		return
+	}
+	fmt.Printf("\n%d rows not imported:\n", len(errs))
+	for _, e := range errs {
+		if e.Line > 0 {
+			fmt.Printf("  line %d: %s\n", e.Line, e.Error)
+		} else {
+			fmt.Printf("  %s\n", e.Error)
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd, importCmd)
+	exportCmd.Flags().StringVar(&exportFormat, "format", "json", "Export format")
+	exportCmd.Flags().MarkDeprecated("format", "pass the format as an argument, e.g. \"chronicle export json\"")
+	exportCmd.Flags().StringVarP(&exportOut, "out", "f", "", "Write to this file instead of stdout")
+	exportCmd.Flags().StringVar(&csvOptions.Type, "type", model.CSVTypeTasks, "CSV: export tasks or worklogs")
+	exportCmd.Flags().StringVar(&csvOptions.Status, "status", "", "CSV tasks: comma separated statuses, e.g. in-progress,todo (default: all)")
+	exportCmd.Flags().StringVarP(&csvOptions.Category, "category", "c", "", "Only tasks of this category (all formats except CSV worklogs)")
+	exportCmd.Flags().BoolVar(&csvOptions.Archived, "archived", false, "CSV tasks: include archived tasks")
+	exportCmd.Flags().StringVar(&csvOptions.From, "from", "", "Start date, YYYY-MM-DD (CSV worklogs: default 30 days ago; task formats: completed since)")
+	exportCmd.Flags().StringVar(&csvOptions.To, "to", "", "End date, YYYY-MM-DD (CSV worklogs: default today; task formats: completed until)")
+	exportCmd.Flags().BoolVar(&exportTodo, "todo", false, "ICS: write VTODO items for task apps instead of VEVENT")
+	exportCmd.Flags().StringVar(&exportDate, "date", "", "Task formats: only tasks completed on this day (YYYY-MM-DD)")
+	exportCmd.Flags().StringSliceVar(&exportIDs, "ids", nil, "Task formats: only these task IDs (comma separated)")
+	exportCmd.Flags().BoolVar(&exportZip, "zip", false, "Task formats: one file per task in a zip archive (default for obsidian and logseq; --zip=false for one file)")
+
+	for _, f := range exporter.Formats() {
+		exportCmd.Long += fmt.Sprintf("  %-9s %s\n", f.Name(), f.Description())
+	}
+
+	importCmd.Flags().StringVar(&importMode, "mode", model.ImportModeMerge, "JSON: merge or replace")
+	importCmd.Flags().StringVar(&csvOptions.Type, "type", model.CSVTypeTasks, "CSV: import tasks or worklogs")
+	importCmd.Flags().StringVar(&csvMapping, "map", "", "CSV: column mapping field=Header,... for files with other headers")
+	importCmd.Flags().StringVarP(&listImport.Category, "category", "c", "", "Task lists: category of tasks without a project or heading (default: default_category, or inbox)")
+	importCmd.Flags().BoolVar(&listImport.DryRun, "dry-run", false, "Task lists: show what would be imported without writing anything")
+}
@@ -0,0 +1,263 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yuyudeqiu/chronicle/internal/config"
+	"github.com/yuyudeqiu/chronicle/internal/importer"
+	"github.com/yuyudeqiu/chronicle/internal/model"
+	"github.com/yuyudeqiu/chronicle/internal/service"
+)
+
+// gitHookMarker identifies a post-commit hook written by chronicle.
+const gitHookMarker = `Installed by "chronicle git hook install"`
+
+var (
+	gitSince    string
+	gitMaxCount int
+	gitDryRun   bool
+	gitQuiet    bool
+	gitForce    bool
+)
+
+var gitCmd = &cobra.Command{
+	Use:   "git",
+	Short: "Log git commits to the tasks they reference",
+	Long: `Log git commits to the tasks they reference.
+
+A commit message references tasks with "chronicle:" followed by one or more
+short IDs, full IDs or ID prefixes, separated by commas, anywhere in the message:
+
+  Fix token refresh
+
+  Chronicle: #12, 3f2b9c1e
+
+Each referenced task gets a worklog with the commit subject and hash, dated
+when the commit was authored. Scanning is idempotent: a commit already logged
+to a task is not logged again.`,
+}
+
+var gitScanCmd = &cobra.Command{
+	Use:   "scan <repo>",
+	Short: "Log the commits of a repository that reference tasks",
+	Long: `Log the commits of the current branch of a repository that reference tasks.
+
+Merge commits are skipped. --since takes any date git understands, such as
+2026-03-01 or "2 weeks ago".`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		commits, err := importer.ReadGitCommits(args[0], gitSince, gitMaxCount)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		result, err := api.ImportCommits(model.CommitImportReq{Commits: commits, DryRun: gitDryRun})
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if jsonOutput {
+			printJSON(result)
+			return
+		}
+		printCommitImport(result)
+	},
+}
+
+func printCommitImport(r *model.CommitImportResult) {
+	verb := "logged"
+	if r.DryRun {
+		verb = "would log"
+	}
+	created := 0
+	for _, l := range r.Logs {
+		if !l.Created {
+			continue
+		}
+		created++
+		fmt.Printf("  %s %.12s -> %s (%.8s)\n", verb, l.Hash, l.TaskTitle, l.TaskID)
+	}
+	for _, e := range r.Errors {
+		fmt.Printf("  warning: commit %.12s references %s: %s\n", e.Hash, e.Ref, e.Error)
+	}
+	if gitQuiet && created == 0 {
+		return
+	}
+	fmt.Printf("Scanned %d commits: %d new worklogs, %d already logged\n", r.Commits, created, len(r.Logs)-created)
+}
+
+var gitHookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "Manage the post-commit hook that logs new commits",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		initConfig(cmd)
+	},
+}
+
+var gitHookInstallCmd = &cobra.Command{
+	Use:   "install [repo]",
+	Short: "Install a post-commit hook that logs each new commit",
+	Long: `Install a post-commit hook that runs "chronicle git scan" on each new commit.
+
+The hook calls this chronicle binary with the global flags given here (such as
+--data-dir or --server), so it logs to the same database. Without a server the
+data directory in use is always written to the hook as an absolute path. An existing
+post-commit hook not written by chronicle is only replaced with --force.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := "."
+		if len(args) == 1 {
+			repo = args[0]
+		}
+		hook, err := gitHookPath(repo)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if existing, err := os.ReadFile(hook); err == nil && !strings.Contains(string(existing), gitHookMarker) && !gitForce {
+			fmt.Printf("Error: %s already exists; use --force to replace it\n", hook)
+			os.Exit(1)
+		}
+
+		script, err := gitHookScript(cmd)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := os.MkdirAll(filepath.Dir(hook), 0755); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := os.WriteFile(hook, []byte(script), 0755); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Installed %s\n", hook)
+	},
+}
+
+var gitHookUninstallCmd = &cobra.Command{
+	Use:   "uninstall [repo]",
+	Short: "Remove the post-commit hook installed by chronicle",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := "."
+		if len(args) == 1 {
+			repo = args[0]
+		}
+		hook, err := gitHookPath(repo)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		existing, err := os.ReadFile(hook)
+		if os.IsNotExist(err) || (err == nil && !strings.Contains(string(existing), gitHookMarker)) {
+			fmt.Printf("Error: %s was not installed by chronicle\n", hook)
+			os.Exit(1)
+		}
+		if err == nil {
+			err = os.Remove(hook)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Removed %s\n", hook)
+	},
+}
+
+// gitHookPath returns the post-commit hook of repo, honouring core.hooksPath.
+func gitHookPath(repo string) (string, error) {
+	out, err := exec.Command("git", "-C", repo, "rev-parse", "--git-path", "hooks").Output()
+	if err != nil {
+		return "", fmt.Errorf("%s is not a git repository", repo)
+	}
+	dir := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repo, dir)
+	}
+	return filepath.Join(dir, "post-commit"), nil
+}
+
+// gitHookScript writes a hook that scans the commit just made, passing on the
+// global flags of the current command with paths made absolute. Git runs hooks in
+// the repository, so without a server the hook also gets the data directory in use
+// (and a relative SQLite database given with --db-dsn or in the config) as absolute
+// paths; otherwise the default "data" would be looked for in the repository.
+func gitHookScript(cmd *cobra.Command) (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	driver, dsn := config.GetDatabase()
+	sqlitePath := func(dsn string) bool {
+		return driver == service.DriverSQLite && isRelativeSQLitePath(dsn)
+	}
+	words := []string{shellQuote(exe)}
+	given := make(map[string]bool)
+	for _, name := range []string{"config", "profile", "data-dir", "db-driver", "db-dsn", "server", "tz"} {
+		f := cmd.Flags().Lookup(name)
+		if f == nil || !f.Changed {
+			continue
+		}
+		value := f.Value.String()
+		if name == "config" || name == "data-dir" || (name == "db-dsn" && sqlitePath(value)) {
+			if value, err = filepath.Abs(value); err != nil {
+				return "", err
+			}
+		}
+		words = append(words, "--"+name, shellQuote(value))
+		given[name] = true
+	}
+	if config.GetServerURL() == "" {
+		if !given["data-dir"] {
+			dir, err := filepath.Abs(config.Load())
+			if err != nil {
+				return "", err
+			}
+			words = append(words, "--data-dir", shellQuote(dir))
+		}
+		if !given["db-dsn"] && dsn != config.GetDBPath() && sqlitePath(dsn) {
+			abs, err := filepath.Abs(dsn)
+			if err != nil {
+				return "", err
+			}
+			words = append(words, "--db-driver", shellQuote(driver), "--db-dsn", shellQuote(abs))
+		}
+	}
+	words = append(words, `git scan "$(git rev-parse --show-toplevel)" --max-count 1 --quiet`)
+
+	return "#!/bin/sh\n" +
+		"# " + gitHookMarker + ": logs each commit that references\n" +
+		"# tasks (\"chronicle: <id>\") to Chronicle. A failure never blocks the commit.\n" +
+		strings.Join(words, " ") + " || true\n", nil
+}
+
+// isRelativeSQLitePath reports whether a SQLite DSN is a plain relative file path,
+// rather than an absolute path or a URI such as "file:..." or ":memory:".
+func isRelativeSQLitePath(dsn string) bool {
+	return dsn != "" && !filepath.IsAbs(dsn) && !strings.Contains(dsn, ":")
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func init() {
+	rootCmd.AddCommand(gitCmd)
+	gitCmd.AddCommand(gitScanCmd, gitHookCmd)
+	gitHookCmd.AddCommand(gitHookInstallCmd, gitHookUninstallCmd)
+
+	gitScanCmd.Flags().StringVar(&gitSince, "since", "", `Only scan commits after this date, e.g. 2026-03-01 or "2 weeks ago"`)
+	gitScanCmd.Flags().IntVarP(&gitMaxCount, "max-count", "n", 0, "Only scan the newest n commits")
+	gitScanCmd.Flags().BoolVar(&gitDryRun, "dry-run", false, "Show what would be logged without writing anything")
+	gitScanCmd.Flags().BoolVarP(&gitQuiet, "quiet", "q", false, "Print nothing when no new worklogs were added")
+	gitHookInstallCmd.Flags().BoolVar(&gitForce, "force", false, "Replace an existing post-commit hook")
+}
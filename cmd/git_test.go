@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestGitHookScript(t *testing.T) {
+	cmd := &cobra.Command{Use: "install"}
+	cmd.Flags().String("data-dir", "", "")
+	cmd.Flags().String("tz", "", "")
+	cmd.Flags().String("server", "", "")
+	if err := cmd.Flags().Set("data-dir", "data"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Flags().Set("tz", "Asia/Shanghai"); err != nil {
+		t.Fatal(err)
+	}
+
+	script, err := gitHookScript(cmd)
+	if err != nil {
+		t.Fatalf("gitHookScript: %v", err)
+	}
+	abs, _ := filepath.Abs("data")
+	for _, want := range []string{
+		"#!/bin/sh\n",
+		gitHookMarker,
+		"--data-dir " + shellQuote(abs),
+		"--tz 'Asia/Shanghai'",
+		`git scan "$(git rev-parse --show-toplevel)" --max-count 1 --quiet || true`,
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("hook script lacks %q:\n%s", want, script)
+		}
+	}
+	if strings.Contains(script, "--server") {
+		t.Errorf("hook script passes a flag that was not set:\n%s", script)
+	}
+}
+
+func TestGitHookScriptDefaultDatabase(t *testing.T) {
+	for _, key := range []string{"CHRONICLE_DATA_DIR", "CHRONICLE_SERVER", "CHRONICLE_DB_DRIVER"} {
+		t.Setenv(key, "")
+	}
+	t.Setenv("CHRONICLE_DB_DSN", "chronicle.db")
+
+	// Without any flags the hook must still find the database from the repository
+	script, err := gitHookScript(&cobra.Command{Use: "install"})
+	if err != nil {
+		t.Fatalf("gitHookScript: %v", err)
+	}
+	dir, _ := filepath.Abs("data")
+	dsn, _ := filepath.Abs("chronicle.db")
+	for _, want := range []string{"--data-dir " + shellQuote(dir), "--db-driver 'sqlite' --db-dsn " + shellQuote(dsn)} {
+		if !strings.Contains(script, want) {
+			t.Errorf("hook script lacks %q:\n%s", want, script)
+		}
+	}
+
+	t.Setenv("CHRONICLE_SERVER", "http://nas.local:8080")
+	script, err = gitHookScript(&cobra.Command{Use: "install"})
+	if err != nil {
+		t.Fatalf("gitHookScript: %v", err)
+	}
+	if strings.Contains(script, "--data-dir") || strings.Contains(script, "--db-dsn") {
+		t.Errorf("hook script for a server passes the local database:\n%s", script)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	if got, want := shellQuote("it's"), `'it'\''s'`; got != want {
+		t.Errorf("shellQuote = %s, want %s", got, want)
+	}
+}
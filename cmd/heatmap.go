@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yuyudeqiu/chronicle/internal/model"
+)
+
+var heatmapDays int
+
+// heatLevels maps worklog intensity (none -> most active) to terminal cells
+var heatLevels = []string{"·", "░", "▒", "▓", "█"}
+
+var heatmapCmd = &cobra.Command{
+	Use:   "heatmap",
+	Short: "Show worklog activity heatmap and streaks",
+	Run: func(cmd *cobra.Command, args []string) {
+		heatmap, err := api.GetWorklogHeatmap(heatmapDays, mustLocation())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if jsonOutput {
+			printJSON(heatmap)
+		} else {
+			printHeatmap(heatmap)
+		}
+	},
+}
+
+// printHeatmap renders a GitHub-style grid: one row per weekday, one column per week.
+func printHeatmap(h *model.HeatmapResp) {
+	if len(h.Days) == 0 {
+		fmt.Println("No activity data")
+		return
+	}
+
+	maxCount := 0
+	for _, d := range h.Days {
+		if d.Count > maxCount {
+			maxCount = d.Count
+		}
+	}
+
+	// Pad the first week so every column starts on Monday
+	first, _ := time.Parse("2006-01-02", h.Days[0].Date)
+	lead := (int(first.Weekday()) + 6) % 7
+	cells := make([]*model.HeatmapDay, lead, lead+len(h.Days))
+	for i := range h.Days {
+		cells = append(cells, &h.Days[i])
+	}
+	weeks := (len(cells) + 6) / 7
+
+	// Month labels above the first column of each month
+	header := []rune(strings.Repeat(" ", weeks+4))
+	lastMonth := time.Month(0)
+	free := 0
+	for w := 0; w < weeks; w++ {
+		for d := 0; d < 7; d++ {
+			idx := w*7 + d
+			if idx >= len(cells) || cells[idx] == nil {
+				continue
+			}
+			date, _ := time.Parse("2006-01-02", cells[idx].Date)
+			if date.Month() != lastMonth {
+				lastMonth = date.Month()
+				label := date.Format("Jan")
+				if pos := 4 + w; pos >= free && pos+len(label) <= len(header) {
+					copy(header[pos:], []rune(label))
+					free = pos + len(label) + 1
+				}
+			}
+			break
+		}
+	}
+	fmt.Println(strings.TrimRight(string(header), " "))
+
+	weekdays := []string{"Mon", "   ", "Wed", "   ", "Fri", "   ", "Sun"}
+	for d := 0; d < 7; d++ {
+		var row strings.Builder
+		row.WriteString(weekdays[d] + " ")
+		for w := 0; w < weeks; w++ {
+			idx := w*7 + d
+			if idx >= len(cells) || cells[idx] == nil {
+				row.WriteString(" ")
+				continue
+			}
+			row.WriteString(heatLevels[heatLevel(cells[idx].Count, maxCount)])
+		}
+		fmt.Println(row.String())
+	}
+
+	fmt.Printf("\n%s to %s: %d worklogs on %d days\n", h.From, h.To, h.TotalLogs, h.ActiveDays)
+	fmt.Printf("Current streak: %d days, longest streak: %d days\n", h.CurrentStreak, h.LongestStreak)
+}
+
+func heatLevel(count, maxCount int) int {
+	if count == 0 || maxCount == 0 {
+		return 0
+	}
+	level := (count*(len(heatLevels)-1) + maxCount - 1) / maxCount
+	if level < 1 {
+		level = 1
+	}
+	return level
+}
+
+func init() {
+	rootCmd.AddCommand(heatmapCmd)
+	heatmapCmd.Flags().IntVar(&heatmapDays, "days", 365, "Number of days to include")
+}
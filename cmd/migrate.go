@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yuyudeqiu/chronicle/internal/config"
+	"github.com/yuyudeqiu/chronicle/internal/service"
+	"gorm.io/gorm"
+)
+
+var (
+	migrateTarget int
+	migrateSteps  int
+)
+
+// migrateDB is opened without applying migrations, unlike the database other commands use.
+var migrateDB *gorm.DB
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Show or change the database schema version",
+	Long: `Show or change the database schema version.
+
+A new, empty database is set up automatically. When an upgrade brings new
+migrations, other commands refuse to run until they are applied with
+"chronicle migrate up" (the server can apply them itself with --auto-migrate).
+Use these commands to inspect the schema, apply migrations, or revert them
+before downgrading.`,
+	// migrate commands always work on the local database and must not migrate it implicitly
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		initConfig(cmd)
+
+		db, err := service.OpenDB(config.GetDatabase())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		migrateDB = db
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "List migrations and whether they have been applied",
+	Run: func(cmd *cobra.Command, args []string) {
+		states, err := service.MigrationStatus(migrateDB)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		version, err := service.SchemaVersion(migrateDB)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if jsonOutput {
+			printJSON(map[string]interface{}{
+				"database":   databaseName(),
+				"version":    version,
+				"latest":     service.LatestSchemaVersion(),
+				"migrations": states,
+			})
+			return
+		}
+
+		fmt.Printf("Database: %s\n", databaseName())
+		fmt.Printf("Schema version: %d (latest: %d)\n\n", version, service.LatestSchemaVersion())
+		for _, s := range states {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = "applied " + s.AppliedAt.Format("2006-01-02 15:04")
+			}
+			fmt.Printf("  %3d  %-40s %s\n", s.Version, s.Name, applied)
+		}
+		if version > service.LatestSchemaVersion() {
+			fmt.Printf("\nWarning: the database was migrated by a newer version of chronicle\n")
+		}
+	},
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply pending migrations",
+	Run: func(cmd *cobra.Command, args []string) {
+		applied, err := service.MigrateUp(migrateDB, migrateTarget)
+		if err != nil {
+			printMigrations("Applied", applied)
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(applied) == 0 && !jsonOutput {
+			fmt.Println("Nothing to do")
+			return
+		}
+		printMigrations("Applied", applied)
+	},
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Revert the most recently applied migrations (drops their tables and data)",
+	Run: func(cmd *cobra.Command, args []string) {
+		if migrateSteps < 1 {
+			fmt.Println("Error: --steps must be at least 1")
+			os.Exit(1)
+		}
+		reverted, err := service.MigrateDown(migrateDB, migrateSteps)
+		if err != nil {
+			printMigrations("Reverted", reverted)
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(reverted) == 0 && !jsonOutput {
+			fmt.Println("Nothing to do")
+			return
+		}
+		printMigrations("Reverted", reverted)
+	},
+}
+
+// databaseName describes the database without printing credentials from the DSN.
+func databaseName() string {
+	driver, dsn := config.GetDatabase()
+	if driver == service.DriverSQLite {
+		return dsn
+	}
+	return driver
+}
+
+func printMigrations(action string, done []service.Migration) {
+	if jsonOutput {
+		type entry struct {
+			Version int    `json:"version"`
+			Name    string `json:"name"`
+		}
+		entries := []entry{}
+		for _, m := range done {
+			entries = append(entries, entry{Version: m.Version, Name: m.Name})
+		}
+		printJSON(map[string]interface{}{"action": action, "migrations": entries})
+		return
+	}
+
+	for _, m := range done {
+		fmt.Printf("%s migration %d: %s\n", action, m.Version, m.Name)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+	migrateCmd.AddCommand(migrateStatusCmd, migrateUpCmd, migrateDownCmd)
+	migrateUpCmd.Flags().IntVar(&migrateTarget, "to", 0, "Only migrate up to this version (default: latest)")
+	migrateDownCmd.Flags().IntVar(&migrateSteps, "steps", 1, "Number of migrations to revert")
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yuyudeqiu/chronicle/internal/config"
+	"github.com/yuyudeqiu/chronicle/internal/exporter"
+	"github.com/yuyudeqiu/chronicle/internal/model"
+)
+
+var obsidianDays int
+
+var obsidianCmd = &cobra.Command{
+	Use:   "obsidian",
+	Short: "Keep an Obsidian vault in sync with your tasks",
+}
+
+var obsidianSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Write one note per task into the vault and worklogs into its daily notes",
+	Long: `Write one note per task into the vault and worklogs into its daily notes.
+
+Task notes go into the configured folder (default Chronicle/) and are named
+"<title> (<short id>).md"; a note is renamed when its task's title changes.
+Anything below the "备注" heading is yours and survives every sync. The
+worklogs of the last --days days are written into a chronicle section of the
+daily notes (YYYY-MM-DD.md), leaving the rest of each note untouched.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dump, err := api.ExportDump()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		cfg := config.GetObsidianConfig()
+		result, err := exporter.SyncVault(dump, exporter.VaultOptions{
+			Vault:       cfg.Vault,
+			Folder:      cfg.Folder,
+			DailyFolder: cfg.DailyFolder,
+			Days:        obsidianDays,
+			Now:         time.Now(),
+		}, mustLocation())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if jsonOutput {
+			printJSON(result)
+			return
+		}
+		printVaultSync(result)
+	},
+}
+
+func printVaultSync(r *model.VaultSyncResult) {
+	for _, p := range r.Created {
+		fmt.Printf("  created  %s\n", p)
+	}
+	for _, p := range r.Renamed {
+		fmt.Printf("  renamed  %s\n", p)
+	}
+	for _, p := range r.Updated {
+		fmt.Printf("  updated  %s\n", p)
+	}
+	for _, p := range r.DailyNotes {
+		fmt.Printf("  daily    %s\n", p)
+	}
+	fmt.Printf("Synced %s: %d created, %d updated, %d unchanged, %d daily notes\n",
+		r.Vault, len(r.Created), len(r.Updated), r.Unchanged, len(r.DailyNotes))
+}
+
+func init() {
+	rootCmd.AddCommand(obsidianCmd)
+	obsidianCmd.AddCommand(obsidianSyncCmd)
+	obsidianSyncCmd.Flags().StringVar(&config.Obsidian.Vault, "vault", "", "Obsidian vault directory (or set obsidian.vault)")
+	obsidianSyncCmd.Flags().StringVar(&config.Obsidian.Folder, "folder", "", "Vault folder for task notes (default Chronicle)")
+	obsidianSyncCmd.Flags().StringVar(&config.Obsidian.DailyFolder, "daily-folder", "", "Vault folder of the daily notes (default: vault root)")
+	obsidianSyncCmd.Flags().IntVar(&obsidianDays, "days", 7, "Update the daily notes of this many days up to today (0 to skip daily notes)")
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yuyudeqiu/chronicle/internal/exporter"
+	"github.com/yuyudeqiu/chronicle/internal/service"
+)
+
+var (
+	reportDate     string
+	reportMarkdown bool
+)
+
+var reportCmd = &cobra.Command{
+	Use:       "report [week|month]",
+	Short:     "Get weekly or monthly report (default: this week)",
+	Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{service.PeriodWeek, service.PeriodMonth},
+	Run: func(cmd *cobra.Command, args []string) {
+		period := service.PeriodWeek
+		if len(args) > 0 {
+			period = args[0]
+		}
+
+		loc := mustLocation()
+		anchor := time.Now().In(loc)
+		if reportDate != "" {
+			t, err := time.ParseInLocation("2006-01-02", reportDate, loc)
+			if err != nil {
+				fmt.Printf("Error: invalid date: %s\n", reportDate)
+				os.Exit(1)
+			}
+			anchor = t
+		}
+
+		from, to, err := service.PeriodRange(period, anchor)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		summary, err := api.GetPeriodSummary(from, to, loc)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if jsonOutput {
+			printJSON(summary)
+			return
+		}
+
+		if reportMarkdown {
+			md, err := exporter.RenderPeriodMarkdown(summary)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Print(string(md))
+			return
+		}
+
+		fmt.Printf("Report for %s ~ %s (%d worklogs)\n", summary.From, summary.To, summary.TotalLogs)
+
+		fmt.Printf("\nCompleted (%d):\n", len(summary.Completed))
+		for _, t := range summary.Completed {
+			fmt.Printf("  ✅ %s [%s] %s\n", t.TaskTitle, t.Category, t.At)
+		}
+		fmt.Printf("\nStarted (%d):\n", len(summary.Started))
+		for _, t := range summary.Started {
+			fmt.Printf("  🔄 %s [%s] %s\n", t.TaskTitle, t.Category, t.At)
+		}
+		fmt.Printf("\nSlipped (%d):\n", len(summary.Slipped))
+		for _, t := range summary.Slipped {
+			fmt.Printf("  ⏰ %s [%s] deadline %s\n", t.TaskTitle, t.Category, t.At)
+		}
+
+		fmt.Println()
+		for _, cat := range summary.Categories {
+			fmt.Printf("### %s\n", cat.Category)
+			for _, t := range cat.Tasks {
+				fmt.Printf("%s (%s)\n", t.TaskTitle, t.Status)
+				for _, log := range t.Logs {
+					fmt.Printf("   - %s\n", log)
+				}
+			}
+			fmt.Println()
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.Flags().StringVar(&reportDate, "date", "", "Any date within the period (YYYY-MM-DD, default: today)")
+	reportCmd.Flags().BoolVarP(&reportMarkdown, "markdown", "m", false, "Output the report as Markdown")
+}
@@ -4,29 +4,75 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/yuyudeqiu/chronicle/internal/client"
 	"github.com/yuyudeqiu/chronicle/internal/config"
 	"github.com/yuyudeqiu/chronicle/internal/service"
 )
 
 var jsonOutput bool
 var dataDir string
+var timezone string
+var templatesDir string
 
 var rootCmd = &cobra.Command{
 	Use:   "chronicle",
 	Short: "Chronicle is a task management tool",
 	Long:  `Chronicle is a task management tool with a CLI and a web interface.`,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		// 初始化配置（命令行参数优先级最高）
-		if dataDir != "" {
-			config.DataDir = dataDir
+		initConfig(cmd)
+
+		// 配置了远程服务时通过 HTTP 调用，否则直接访问本地数据库
+		if serverURL := config.GetServerURL(); serverURL != "" {
+			remote, err := client.NewRemote(serverURL)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			api = remote
+			return
 		}
-		// 初始化数据库
-		service.InitDB(config.GetDBPath())
+
+		// 初始化数据库；CLI 从不自动执行迁移，需先运行 chronicle migrate up
+		driver, dsn := config.GetDatabase()
+		service.InitDB(driver, dsn, false)
+		api = client.NewLocal()
 	},
 }
 
+// api is the backend used by task commands: the local database or a remote server.
+var api client.Client
+
+// initConfig 初始化配置（命令行参数 > 环境变量 > 配置文件 > 默认值）
+func initConfig(cmd *cobra.Command) {
+	if err := loadConfig(cmd); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func loadConfig(cmd *cobra.Command) error {
+	if dataDir != "" {
+		config.DataDir = dataDir
+	}
+	if timezone != "" {
+		config.Timezone = timezone
+	}
+	if templatesDir != "" {
+		config.TemplatesDir = templatesDir
+	}
+
+	err := config.LoadFile()
+
+	// 未显式传入 --json 时，使用配置的默认输出格式
+	if !cmd.Flags().Changed("json") && config.GetOutput() == "json" {
+		jsonOutput = true
+	}
+	return err
+}
+
 // JSON output helper
 func printJSON(v interface{}) {
 	data, err := json.MarshalIndent(v, "", "  ")
@@ -37,6 +83,16 @@ func printJSON(v interface{}) {
 	fmt.Println(string(data))
 }
 
+// mustLocation returns the configured timezone, exiting on an invalid name.
+func mustLocation() *time.Location {
+	loc, err := config.GetLocation()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	return loc
+}
+
 // Execute executes the root command.
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
@@ -48,4 +104,11 @@ func Execute() {
 func init() {
 	rootCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "o", false, "Output in JSON format")
 	rootCmd.PersistentFlags().StringVar(&dataDir, "data-dir", "", "Data directory (default: data/, or use CHRONICLE_DATA_DIR env var)")
+	rootCmd.PersistentFlags().StringVar(&timezone, "tz", "", "Timezone for day boundaries, e.g. Asia/Shanghai (default: system local, or use CHRONICLE_TZ env var)")
+	rootCmd.PersistentFlags().StringVar(&config.DBDriver, "db-driver", "", "Database driver: sqlite, postgres or mysql (default: sqlite, or use CHRONICLE_DB_DRIVER env var)")
+	rootCmd.PersistentFlags().StringVar(&config.DBDSN, "db-dsn", "", "Database connection string (default: app.db in the data directory, or use CHRONICLE_DB_DSN env var)")
+	rootCmd.PersistentFlags().StringVar(&config.ServerURL, "server", "", "Use a running Chronicle server at this URL instead of the local database (or use CHRONICLE_SERVER env var)")
+	rootCmd.PersistentFlags().StringVar(&config.ConfigPath, "config", "", "Config file (default: ~/.config/chronicle/config.yaml, or use CHRONICLE_CONFIG env var)")
+	rootCmd.PersistentFlags().StringVar(&config.Profile, "profile", "", "Config profile to use, e.g. work or personal (or use CHRONICLE_PROFILE env var)")
+	rootCmd.PersistentFlags().StringVar(&templatesDir, "templates-dir", "", "Directory with custom export templates overriding the built-in ones (or use CHRONICLE_TEMPLATES_DIR env var)")
 }
@@ -1,55 +1,255 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"io/fs"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/cobra"
+	"github.com/yuyudeqiu/chronicle/frontend"
 	"github.com/yuyudeqiu/chronicle/internal/config"
+	"github.com/yuyudeqiu/chronicle/internal/exporter"
 	"github.com/yuyudeqiu/chronicle/internal/handler"
 	"github.com/yuyudeqiu/chronicle/internal/service"
 )
 
+// serverDev serves the frontend and templates from the working directory instead of
+// the copies embedded in the binary, so rebuilt assets show up without recompiling.
+var serverDev bool
+
 var serverCmd = &cobra.Command{
 	Use:   "server",
 	Short: "Start the chronicle web server",
+	// the server always uses its own database and migrates it only if configured to
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		initConfig(cmd)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		// 初始化配置
 		if dataDir != "" {
 			config.DataDir = dataDir
 		}
 
+		loc, err := config.GetLocation()
+		if err != nil {
+			log.Fatalf("Invalid timezone: %v", err)
+		}
+
+		cfg, err := config.GetServerConfig()
+		if err != nil {
+			log.Fatalf("Invalid server configuration: %v", err)
+		}
+
 		// Initialize database
-		service.InitDB(config.GetDBPath())
+		driver, dsn := config.GetDatabase()
+		service.InitDB(driver, dsn, cfg.AutoMigrate)
 
 		// Get current working directory
 		dir, _ := os.Getwd()
 		log.Printf("Working directory: %s", dir)
-		log.Printf("Data directory: %s", config.Load())
+		if driver, _ := config.GetDatabase(); driver == service.DriverSQLite {
+			log.Printf("Data directory: %s", config.Load())
+		} else {
+			log.Printf("Database: %s", driver)
+		}
+		log.Printf("Timezone: %s", loc)
+
+		if serverDev {
+			if config.GetTemplatesDir() == "" {
+				config.TemplatesDir = "templates"
+			}
+			log.Printf("Dev mode: serving frontend from ./frontend/dist, templates from %s", config.GetTemplatesDir())
+		}
 
 		// Setup router
 		r := gin.Default()
 
 		// Register APIs
-		handler.RegisterRoutes(r)
+		handler.RegisterRoutes(r.Group(cfg.BasePath))
 
-		// Serve the frontend build output for static assets
-		r.Static("/assets", "./frontend/dist/assets")
-
-		// Serve index.html for all other routes (SPA fallback)
-		r.NoRoute(func(c *gin.Context) {
-			c.File("./frontend/dist/index.html")
-		})
+		// Serve the frontend build output (embedded, or from disk in dev mode)
+		registerFrontend(r, frontendFS(), cfg.BasePath)
 
 		// Start server
-		log.Println("Starting server on :8080...")
-		if err := r.Run(":8080"); err != nil {
-			log.Fatalf("Server failed to start: %v", err)
+		srv := &http.Server{
+			Addr:    cfg.Listen,
+			Handler: r,
 		}
+
+		go func() {
+			var err error
+			if cfg.TLSCert != "" {
+				log.Printf("Starting server on %s%s (HTTPS)...", cfg.Listen, cfg.BasePath)
+				err = srv.ListenAndServeTLS(cfg.TLSCert, cfg.TLSKey)
+			} else {
+				log.Printf("Starting server on %s%s...", cfg.Listen, cfg.BasePath)
+				err = srv.ListenAndServe()
+			}
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatalf("Server failed to start: %v", err)
+			}
+		}()
+
+		// Wait for SIGINT/SIGTERM, then let in-flight requests (and their DB transactions) finish
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if driver, _ := config.GetDatabase(); (cfg.SnapshotDaily > 0 || cfg.SnapshotWeekly > 0) && driver != service.DriverSQLite {
+			log.Printf("Snapshots disabled: %v", service.ErrBackupUnsupported)
+		} else if cfg.SnapshotDaily > 0 || cfg.SnapshotWeekly > 0 {
+			log.Printf("Snapshots: keeping %d daily and %d weekly in %s", cfg.SnapshotDaily, cfg.SnapshotWeekly, config.GetSnapshotDir())
+			go runSnapshots(ctx, cfg, loc)
+		}
+
+		if vault := config.GetObsidianConfig().Vault; cfg.ObsidianSync > 0 && vault == "" {
+			log.Printf("Obsidian sync disabled: obsidian.vault is not set")
+		} else if cfg.ObsidianSync > 0 {
+			log.Printf("Obsidian sync: every %s into %s", cfg.ObsidianSync, vault)
+			go runObsidianSync(ctx, cfg.ObsidianSync, loc)
+		}
+
+		<-ctx.Done()
+		stop()
+
+		log.Printf("Shutting down server (timeout %s)...", cfg.ShutdownTimeout)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Server forced to shut down: %v", err)
+		}
+
+		if err := service.CloseDB(); err != nil {
+			log.Printf("Failed to close database: %v", err)
+		}
+		log.Println("Server stopped")
 	},
 }
 
+// runSnapshots takes the scheduled snapshots on startup and then hourly, so a day
+// missed while the server was down is caught up as soon as it runs again.
+func runSnapshots(ctx context.Context, cfg config.ServerConfig, loc *time.Location) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		written, err := service.TakeSnapshots(config.GetSnapshotDir(), time.Now(), cfg.SnapshotDaily, cfg.SnapshotWeekly, loc)
+		for _, path := range written {
+			log.Printf("Snapshot written to %s", path)
+		}
+		if err != nil {
+			log.Printf("Snapshot failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runObsidianSync syncs the configured Obsidian vault on startup and then every interval.
+func runObsidianSync(ctx context.Context, interval time.Duration, loc *time.Location) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := syncObsidian(loc); err != nil {
+			log.Printf("Obsidian sync failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func syncObsidian(loc *time.Location) error {
+	dump, err := service.ExportDump()
+	if err != nil {
+		return err
+	}
+	cfg := config.GetObsidianConfig()
+	result, err := exporter.SyncVault(dump, exporter.VaultOptions{
+		Vault:       cfg.Vault,
+		Folder:      cfg.Folder,
+		DailyFolder: cfg.DailyFolder,
+		// Yesterday as well, for worklogs added after the last sync of the day
+		Days: 2,
+		Now:  time.Now(),
+	}, loc)
+	if err != nil {
+		return err
+	}
+	if n := len(result.Created) + len(result.Updated) + len(result.DailyNotes); n > 0 {
+		log.Printf("Obsidian sync: %d notes created, %d updated, %d daily notes", len(result.Created), len(result.Updated), len(result.DailyNotes))
+	}
+	return nil
+}
+
+func frontendFS() fs.FS {
+	if serverDev {
+		return os.DirFS("frontend/dist")
+	}
+	dist, err := fs.Sub(frontend.Dist, "dist")
+	if err != nil {
+		log.Fatalf("Failed to load embedded frontend: %v", err)
+	}
+	return dist
+}
+
+func registerFrontend(r *gin.Engine, dist fs.FS, basePath string) {
+	httpFS := http.FS(dist)
+
+	// Serve existing files as-is and index.html for all other routes (SPA fallback)
+	r.NoRoute(func(c *gin.Context) {
+		reqPath := path.Clean(c.Request.URL.Path)
+		if basePath != "" {
+			if reqPath != basePath && !strings.HasPrefix(reqPath, basePath+"/") {
+				c.String(http.StatusNotFound, "404 page not found")
+				return
+			}
+			reqPath = strings.TrimPrefix(reqPath, basePath)
+		}
+
+		name := strings.TrimPrefix(reqPath, "/")
+		if info, err := fs.Stat(dist, name); err == nil && !info.IsDir() && name != "index.html" {
+			c.FileFromFS(name, httpFS)
+			return
+		}
+
+		index, err := fs.ReadFile(dist, "index.html")
+		if err != nil {
+			c.String(http.StatusNotFound, "Frontend is not built: run `make frontend-build` and rebuild chronicle")
+			return
+		}
+		// The UI uses relative URLs, so pin them to the base path
+		index = []byte(strings.Replace(string(index), "<head>", `<head><base href="`+basePath+`/">`, 1))
+		c.Data(http.StatusOK, "text/html; charset=utf-8", index)
+	})
+}
+
 func init() {
 	rootCmd.AddCommand(serverCmd)
+	serverCmd.Flags().BoolVar(&serverDev, "dev", false, "Serve frontend and templates from disk instead of the embedded copies")
+	serverCmd.Flags().StringVar(&config.Server.Listen, "listen", "", "Listen address, e.g. 127.0.0.1:8080 (default :8080, or use CHRONICLE_LISTEN env var)")
+	serverCmd.Flags().StringVar(&config.Server.TLSCert, "tls-cert", "", "TLS certificate file; enables HTTPS together with --tls-key")
+	serverCmd.Flags().StringVar(&config.Server.TLSKey, "tls-key", "", "TLS private key file")
+	serverCmd.Flags().StringVar(&config.Server.BasePath, "base-path", "", "URL path prefix when served behind a reverse proxy, e.g. /chronicle")
+	serverCmd.Flags().DurationVar(&config.Server.ShutdownTimeout, "shutdown-timeout", 0, "Time to wait for in-flight requests on shutdown (default 10s)")
+	serverCmd.Flags().IntVar(&config.Server.SnapshotDaily, "snapshot-daily", 0, "Take a daily snapshot of the SQLite database into <data-dir>/backups and keep this many")
+	serverCmd.Flags().IntVar(&config.Server.SnapshotWeekly, "snapshot-weekly", 0, "Take a weekly snapshot as well and keep this many")
+	serverCmd.Flags().BoolVar(&config.Server.AutoMigrate, "auto-migrate", false, "Apply pending database migrations on startup instead of refusing to start (or use CHRONICLE_AUTO_MIGRATE env var)")
+	serverCmd.Flags().DurationVar(&config.Server.ObsidianSync, "obsidian-sync", 0, "Sync the Obsidian vault set in obsidian.vault at this interval, e.g. 1h")
 }
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yuyudeqiu/chronicle/internal/exporter"
+)
+
+var siteOut string
+var siteWeeks int
+var siteDays int
+
+var siteCmd = &cobra.Command{
+	Use:   "site",
+	Short: "Publish tasks as a static HTML site",
+}
+
+var siteBuildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Render a read-only static HTML site of tasks, worklogs and stats",
+	Long: `Render a read-only static HTML site of tasks, worklogs and stats.
+
+The site has an index of open tasks by category, a page per task with its full
+worklog timeline, the tasks completed this week and a stats page with charts.
+Pages only link to each other and need no server or network access, so the
+directory can be copied to any web server or opened locally. Archived tasks
+are left out, and pages of tasks that no longer exist are removed.
+
+The pages written are recorded in .chronicle-site.json in the output directory,
+and only pages listed there are ever removed. Building into a non-empty
+directory without that file is refused, so --out cannot clobber other files.
+
+The pages are rendered with site.tmpl, which can be customised like the export
+templates (see chronicle template).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		loc := mustLocation()
+		data := exporter.SiteData{Now: time.Now()}
+
+		exitOn := func(err error) {
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		var err error
+		data.Dump, err = api.ExportDump()
+		exitOn(err)
+		data.Stats, err = api.GetStatsSummary(loc)
+		exitOn(err)
+		data.Flow, err = api.GetFlowStats(siteWeeks, "", loc)
+		exitOn(err)
+		data.Heatmap, err = api.GetWorklogHeatmap(siteDays, loc)
+		exitOn(err)
+
+		result, err := exporter.BuildSite(siteOut, data, loc)
+		exitOn(err)
+
+		if jsonOutput {
+			printJSON(result)
+			return
+		}
+		fmt.Printf("Built %d pages in %s\n", len(result.Pages), result.Dir)
+		for _, p := range result.Removed {
+			fmt.Printf("  removed %s\n", p)
+		}
+	},
+}
+
+func init() {
+	siteBuildCmd.Flags().StringVar(&siteOut, "out", "site", "Directory to write the site into")
+	siteBuildCmd.Flags().IntVar(&siteWeeks, "weeks", 12, "Number of weeks in the throughput chart")
+	siteBuildCmd.Flags().IntVar(&siteDays, "days", 365, "Number of days in the worklog heatmap")
+
+	siteCmd.AddCommand(siteBuildCmd)
+	rootCmd.AddCommand(siteCmd)
+}
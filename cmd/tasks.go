@@ -7,8 +7,8 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/yuyudeqiu/chronicle/internal/config"
 	"github.com/yuyudeqiu/chronicle/internal/model"
-	"github.com/yuyudeqiu/chronicle/internal/service"
 )
 
 // Task Flags
@@ -16,9 +16,12 @@ var (
 	category string
 	desc     string
 	links    string
+	tags     string
+	parent   string
 	targets  string
 	deadline string
 	status   string
+	listRef  string
 )
 
 var createCmd = &cobra.Command{
@@ -29,16 +32,22 @@ var createCmd = &cobra.Command{
 		title := args[0]
 		deadlineTime := parseDeadline(deadline)
 
+		if category == "" {
+			category = config.GetDefaultCategory()
+		}
+
 		req := model.CreateTaskReq{
 			Title:       title,
 			Category:    category,
 			Description: desc,
 			Targets:     targets,
-			Links:       links,
+			Links:       model.ParseLinks(links),
+			Tags:        tags,
+			ParentID:    parent,
 			Deadline:    deadlineTime,
 		}
 
-		task, err := service.CreateTask(req)
+		task, err := api.CreateTask(req)
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
@@ -47,7 +56,7 @@ var createCmd = &cobra.Command{
 		if jsonOutput {
 			printJSON(task)
 		} else {
-			fmt.Printf("Task created: %s\n", task.ID)
+			fmt.Printf("Task created: %s\n", displayTaskID(task.ShortID, task.ID))
 			printTask(task)
 		}
 	},
@@ -57,20 +66,42 @@ var listCmd = &cobra.Command{
 	Use:   "list [status]",
 	Short: "List tasks",
 	Run: func(cmd *cobra.Command, args []string) {
+		if listRef != "" {
+			found, err := api.FindTasksByRef(listRef)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			if jsonOutput {
+				printJSON(found)
+				return
+			}
+			if len(found) == 0 {
+				fmt.Println("No tasks found")
+				return
+			}
+			fmt.Printf("Found %d tasks:\n\n", len(found))
+			for _, t := range found {
+				fmt.Printf("  [%s] %s - %s\n", t.Status, t.Title, t.Category)
+				fmt.Printf("    ID: %s\n\n", displayTaskID(t.ShortID, t.ID))
+			}
+			return
+		}
+
 		var tasks []model.ActiveTaskResp
 		var err error
 
 		if len(args) > 0 {
 			queryStatus := args[0]
 			if queryStatus == "done" {
-				tasks, err = service.GetHistoryTasks()
+				tasks, err = api.GetHistoryTasks()
 			} else {
 				// For cobra, we could handle other status specifically if needed, 
 				// but following original logic:
-				tasks, err = service.GetActiveTasks()
+				tasks, err = api.GetActiveTasks()
 			}
 		} else {
-			tasks, err = service.GetActiveTasks()
+			tasks, err = api.GetActiveTasks()
 		}
 
 		if err != nil {
@@ -93,7 +124,7 @@ var listCmd = &cobra.Command{
 			fmt.Printf("Found %d tasks:\n\n", len(tasks))
 			for _, t := range tasks {
 				fmt.Printf("  [%s] %s - %s\n", t.Status, t.Title, t.Category)
-				fmt.Printf("    ID: %s\n\n", t.ID)
+				fmt.Printf("    ID: %s\n\n", displayTaskID(t.ShortID, t.ID))
 			}
 		}
 	},
@@ -105,7 +136,7 @@ var getCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		taskID := args[0]
-		task, err := service.GetTask(taskID)
+		task, err := api.GetTask(taskID)
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
@@ -119,7 +150,7 @@ var getCmd = &cobra.Command{
 			if len(task.Logs) > 0 {
 				fmt.Println("\nWorklogs:")
 				for _, log := range task.Logs {
-					fmt.Printf("  [%s] %s\n", log.CreatedAt.Format("2006-01-02 15:04"), log.LogText)
+					fmt.Printf("  [%s] %s\n", log.CreatedAt.In(mustLocation()).Format("2006-01-02 15:04"), log.LogText)
 				}
 			}
 		}
@@ -138,7 +169,7 @@ var updateCmd = &cobra.Command{
 			progressReq := model.UpdateProgressReq{
 				NewStatus: status,
 			}
-			err := service.UpdateProgress(taskID, progressReq)
+			err := api.UpdateProgress(taskID, progressReq)
 			if err != nil {
 				fmt.Printf("Error: %v\n", err)
 				os.Exit(1)
@@ -155,11 +186,21 @@ var updateCmd = &cobra.Command{
 			Category:    category,
 			Description: desc,
 			Targets:     targets,
-			Links:       links,
 			Deadline:    deadlineTime,
 		}
+		// An empty --links, --tags or --parent clears them, so only send what was given
+		if cmd.Flags().Changed("links") {
+			parsed := model.ParseLinks(links)
+			req.Links = &parsed
+		}
+		if cmd.Flags().Changed("tags") {
+			req.Tags = &tags
+		}
+		if cmd.Flags().Changed("parent") {
+			req.ParentID = &parent
+		}
 
-		task, err := service.UpdateTask(taskID, req)
+		task, err := api.UpdateTask(taskID, req)
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
@@ -180,7 +221,7 @@ var deleteCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		taskID := args[0]
-		err := service.DeleteTask(taskID)
+		err := api.DeleteTask(taskID)
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
@@ -206,7 +247,7 @@ var logCmd = &cobra.Command{
 			LogText: logText,
 		}
 
-		err := service.UpdateProgress(taskID, req)
+		err := api.UpdateProgress(taskID, req)
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
@@ -229,7 +270,7 @@ var summaryCmd = &cobra.Command{
 			dateStr = args[0]
 		}
 
-		summary, err := service.GetDailySummary(dateStr)
+		summary, err := api.GetDailySummary(dateStr, mustLocation())
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
@@ -270,7 +311,7 @@ var statsCmd = &cobra.Command{
 	Use:   "stats",
 	Short: "Get task statistics",
 	Run: func(cmd *cobra.Command, args []string) {
-		stats, err := service.GetStatsSummary()
+		stats, err := api.GetStatsSummary(mustLocation())
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
@@ -299,7 +340,43 @@ var statsCmd = &cobra.Command{
 	},
 }
 
+var flowWeeks int
+
+var statsFlowCmd = &cobra.Command{
+	Use:   "flow",
+	Short: "Get lead time, cycle time and throughput metrics",
+	Run: func(cmd *cobra.Command, args []string) {
+		flow, err := api.GetFlowStats(flowWeeks, category, mustLocation())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if jsonOutput {
+			printJSON(flow)
+		} else {
+			fmt.Printf("=== Flow Metrics (since %s) ===\n", flow.Since)
+			printDurationStats("Lead Time", flow.LeadTime)
+			printDurationStats("Cycle Time", flow.CycleTime)
+
+			fmt.Println("\n=== By Category ===")
+			for cat, s := range flow.ByCategory {
+				fmt.Printf("  %s: completed=%d, lead p50=%.1fh, cycle p50=%.1fh\n", cat, s.Completed, s.LeadTime.P50Hours, s.CycleTime.P50Hours)
+			}
+
+			fmt.Println("\n=== Weekly Throughput ===")
+			for _, w := range flow.Throughput {
+				fmt.Printf("  %s: %d\n", w.WeekStart, w.Completed)
+			}
+		}
+	},
+}
+
 // Helpers
+func printDurationStats(name string, s model.DurationStats) {
+	fmt.Printf("%s: n=%d, avg=%.1fh, p50=%.1fh, p85=%.1fh, p95=%.1fh\n", name, s.Count, s.AvgHours, s.P50Hours, s.P85Hours, s.P95Hours)
+}
+
 func parseDeadline(s string) *time.Time {
 	if s == "" {
 		return nil
@@ -312,22 +389,44 @@ func parseDeadline(s string) *time.Time {
 	return &t
 }
 
+// displayTaskID shows the short id of a task, which any command taking an id
+// accepts, before its full id.
+func displayTaskID(shortID int, id string) string {
+	if shortID == 0 {
+		return id
+	}
+	return fmt.Sprintf("#%d (%s)", shortID, id)
+}
+
 func printTask(task *model.Task) {
 	fmt.Println("\nTask Details:")
-	fmt.Printf("  ID: %s\n", task.ID)
+	fmt.Printf("  ID: %s\n", displayTaskID(task.ShortID, task.ID))
 	fmt.Printf("  Title: %s\n", task.Title)
 	fmt.Printf("  Category: %s\n", task.Category)
 	fmt.Printf("  Status: %s\n", task.Status)
+	if task.Tags != "" {
+		fmt.Printf("  Tags: %s\n", task.Tags)
+	}
+	if task.ParentID != "" {
+		fmt.Printf("  Parent: %s\n", task.ParentID)
+	}
+	if task.ExternalRef != "" {
+		fmt.Printf("  External: %s\n", task.ExternalRef)
+	}
 	if task.Deadline != nil {
-		fmt.Printf("  Deadline: %s\n", task.Deadline.Format("2006-01-02 15:04"))
+		fmt.Printf("  Deadline: %s\n", task.Deadline.In(mustLocation()).Format("2006-01-02 15:04"))
 	}
 	if task.Description != "" {
 		fmt.Printf("  Description: %s\n", task.Description)
 	}
-	if task.Links != "" {
+	if len(task.Links) > 0 {
 		fmt.Printf("  Links:\n")
-		for _, link := range strings.Split(task.Links, "\n") {
-			fmt.Printf("    - %s\n", link)
+		for _, link := range task.Links {
+			if link.Label != "" {
+				fmt.Printf("    - [%s] %s: %s\n", link.Kind, link.Label, link.URL)
+			} else {
+				fmt.Printf("    - [%s] %s\n", link.Kind, link.URL)
+			}
 		}
 	}
 }
@@ -337,16 +436,26 @@ func init() {
 	rootCmd.AddCommand(createCmd, listCmd, getCmd, updateCmd, deleteCmd, logCmd, summaryCmd, statsCmd)
 
 	// Local flags for create and update
-	createCmd.Flags().StringVarP(&category, "category", "c", "", "Task category")
+	createCmd.Flags().StringVarP(&category, "category", "c", "", "Task category (default: default_category from config)")
 	createCmd.Flags().StringVarP(&desc, "desc", "d", "", "Task description")
-	createCmd.Flags().StringVarP(&links, "links", "l", "", "Task links (one per line)")
+	createCmd.Flags().StringVarP(&links, "links", "l", "", "Task links, one per line: a URL or [label](URL)")
+	createCmd.Flags().StringVar(&tags, "tags", "", "Comma-separated task tags")
+	createCmd.Flags().StringVar(&parent, "parent", "", "Make this a subtask of the given task")
 	createCmd.Flags().StringVarP(&targets, "target", "t", "", "Task targets")
 	createCmd.Flags().StringVar(&deadline, "deadline", "", "Deadline (ISO8601 format)")
 
 	updateCmd.Flags().StringVarP(&category, "category", "c", "", "Task category")
 	updateCmd.Flags().StringVarP(&desc, "desc", "d", "", "Task description")
-	updateCmd.Flags().StringVarP(&links, "links", "l", "", "Task links (one per line)")
+	updateCmd.Flags().StringVarP(&links, "links", "l", "", "Replace the task links, one per line: a URL or [label](URL)")
+	updateCmd.Flags().StringVar(&tags, "tags", "", "Replace the task tags, comma-separated (empty clears them)")
+	updateCmd.Flags().StringVar(&parent, "parent", "", "Move under the given task (empty makes it top-level)")
 	updateCmd.Flags().StringVarP(&targets, "target", "t", "", "Task targets")
 	updateCmd.Flags().StringVar(&deadline, "deadline", "", "Deadline (ISO8601 format)")
 	updateCmd.Flags().StringVar(&status, "new-status", "", "New status")
+
+	listCmd.Flags().StringVar(&listRef, "ref", "", "Find tasks, archived ones included, linking to this URL or imported from this issue (e.g. github:owner/repo#12)")
+
+	statsCmd.AddCommand(statsFlowCmd)
+	statsFlowCmd.Flags().IntVar(&flowWeeks, "weeks", 8, "Number of weeks to include")
+	statsFlowCmd.Flags().StringVarP(&category, "category", "c", "", "Only include this category")
 }
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yuyudeqiu/chronicle/internal/exporter"
+)
+
+var templateFormat string
+var templatePrint bool
+
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "List, show and validate export templates",
+	Long: `List, show and validate export templates.
+
+Every export format can be customised by a template file of the same name in
+the --templates-dir directory or in templates/ under the data directory, which
+are searched in that order before the built-in templates.`,
+	// templates are local files; no database or server is needed
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		initConfig(cmd)
+	},
+}
+
+var templateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the templates of every export format and where they are loaded from",
+	Run: func(cmd *cobra.Command, args []string) {
+		list := exporter.Templates()
+		if jsonOutput {
+			printJSON(list)
+			return
+		}
+
+		for _, t := range list {
+			source := "built-in"
+			switch {
+			case t.Path != "":
+				source = t.Path
+			case !t.Builtin:
+				source = "rendered by chronicle"
+			}
+			fmt.Printf("%-10s %-22s %s\n", t.Format, t.Name, source)
+		}
+		fmt.Printf("\nUser templates are looked up in: %s\n", strings.Join(exporter.TemplateDirs(), ", "))
+	},
+}
+
+var templateShowCmd = &cobra.Command{
+	Use:   "show <format|name>",
+	Short: "Print the template in effect for a format, to start a custom one from",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		t, ok := exporter.LookupTemplate(args[0])
+		if !ok {
+			fmt.Printf("Error: unknown template %q\n", args[0])
+			os.Exit(1)
+		}
+		src, err := exporter.TemplateSource(t)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(string(src))
+	},
+}
+
+var templateValidateCmd = &cobra.Command{
+	Use:   "validate [file...]",
+	Short: "Render templates against sample data to catch errors before an export does",
+	Long: `Render templates against sample data to catch errors before an export does.
+
+Without arguments, every user template in effect is validated. A file is
+matched to its format by name (e.g. obsidian_task.tmpl); use --format for
+files with other names.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		type result struct {
+			Template string `json:"template"`
+			Format   string `json:"format"`
+			File     string `json:"file"`
+			Error    string `json:"error,omitempty"`
+			Output   string `json:"output,omitempty"`
+		}
+
+		type target struct {
+			info exporter.TemplateInfo
+			path string
+		}
+		var targets []target
+		if len(args) == 0 {
+			for _, t := range exporter.Templates() {
+				if t.Path != "" {
+					targets = append(targets, target{t, t.Path})
+				}
+			}
+			if len(targets) == 0 {
+				fmt.Printf("No user templates found in %s\n", strings.Join(exporter.TemplateDirs(), ", "))
+				return
+			}
+		}
+		for _, path := range args {
+			name := templateFormat
+			if name == "" {
+				name = filepath.Base(path)
+			}
+			t, ok := exporter.LookupTemplate(name)
+			if !ok {
+				fmt.Printf("Error: cannot tell which format %s is for; pass --format\n", path)
+				os.Exit(1)
+			}
+			targets = append(targets, target{t, path})
+		}
+
+		loc := mustLocation()
+		var results []result
+		failed := false
+		for _, tg := range targets {
+			r := result{Template: tg.info.Name, Format: tg.info.Format, File: tg.path}
+			out, err := exporter.ValidateTemplate(tg.info, tg.path, loc)
+			if err != nil {
+				r.Error = err.Error()
+				failed = true
+			} else if templatePrint {
+				r.Output = string(out)
+			}
+			results = append(results, r)
+		}
+
+		if jsonOutput {
+			printJSON(results)
+		} else {
+			for _, r := range results {
+				if r.Error != "" {
+					fmt.Printf("FAIL %s (%s): %s\n", r.File, r.Format, r.Error)
+					continue
+				}
+				fmt.Printf("ok   %s (%s)\n", r.File, r.Format)
+				if r.Output != "" {
+					fmt.Println(r.Output)
+				}
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	templateValidateCmd.Flags().StringVar(&templateFormat, "format", "", "Format (or template name) to validate the files as")
+	templateValidateCmd.Flags().BoolVar(&templatePrint, "print", false, "Print the rendered sample output")
+
+	templateCmd.AddCommand(templateListCmd, templateShowCmd, templateValidateCmd)
+	rootCmd.AddCommand(templateCmd)
+}
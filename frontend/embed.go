@@ -0,0 +1,11 @@
+// Package frontend exposes the built web UI so it can be embedded into the binary.
+package frontend
+
+import "embed"
+
+// Dist holds the Vite build output. The release workflow commits a fresh build before
+// tagging, so tagged versions embed the UI; between releases run `npm run build` (or
+// `make frontend-build`) before `go build` to pick up frontend changes.
+//
+//go:embed all:dist
+var Dist embed.FS
@@ -4,6 +4,8 @@ import tailwindcss from '@tailwindcss/vite'
 
 // https://vite.dev/config/
 export default defineConfig({
+  // Relative asset and API paths so the UI also works when served under --base-path
+  base: './',
   plugins: [
     vue(),
     tailwindcss(),
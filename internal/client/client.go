@@ -0,0 +1,159 @@
+// Package client lets the CLI run the same task operations either directly against
+// the local database or against a running Chronicle server over its REST API.
+package client
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/yuyudeqiu/chronicle/internal/exporter"
+	"github.com/yuyudeqiu/chronicle/internal/importer"
+	"github.com/yuyudeqiu/chronicle/internal/model"
+	"github.com/yuyudeqiu/chronicle/internal/service"
+)
+
+// Client is the set of operations exposed by the CLI.
+type Client interface {
+	CreateTask(req model.CreateTaskReq) (*model.Task, error)
+	GetActiveTasks() ([]model.ActiveTaskResp, error)
+	GetHistoryTasks() ([]model.ActiveTaskResp, error)
+	GetTask(id string) (*model.Task, error)
+	FindTasksByRef(ref string) ([]model.Task, error)
+	UpdateTask(id string, req model.UpdateTaskReq) (*model.Task, error)
+	UpdateProgress(id string, req model.UpdateProgressReq) error
+	DeleteTask(id string) error
+	GetDailySummary(dateStr string, loc *time.Location) (*model.DailySummaryResp, error)
+	GetPeriodSummary(fromStr, toStr string, loc *time.Location) (*model.PeriodSummaryResp, error)
+	GetStatsSummary(loc *time.Location) (*model.StatsSummaryResp, error)
+	GetFlowStats(weeks int, category string, loc *time.Location) (*model.FlowStatsResp, error)
+	GetWorklogHeatmap(days int, loc *time.Location) (*model.HeatmapResp, error)
+	GetAgentContext(budget int, loc *time.Location) (*model.AgentContextResp, error)
+	ExportDump() (*model.Dump, error)
+	ImportDump(dump *model.Dump, mode string) (*model.ImportResult, error)
+	ExportCSV(req model.CSVExportReq, loc *time.Location) ([]byte, error)
+	ImportCSV(data []byte, kind, mapping string, loc *time.Location) (*model.FileImportResult, error)
+	ImportTaskList(data []byte, format string, req model.TaskListImportReq, loc *time.Location) (*model.FileImportResult, error)
+	ImportCommits(req model.CommitImportReq) (*model.CommitImportResult, error)
+	ExportICS(req model.CalendarReq) ([]byte, error)
+	Export(format string, req model.ExportReq, loc *time.Location) ([]byte, error)
+}
+
+// Local runs operations in-process through the service layer. service.InitDB
+// must have been called first.
+type Local struct{}
+
+func NewLocal() *Local {
+	return &Local{}
+}
+
+func (Local) CreateTask(req model.CreateTaskReq) (*model.Task, error) {
+	return service.CreateTask(req)
+}
+
+func (Local) GetActiveTasks() ([]model.ActiveTaskResp, error) {
+	return service.GetActiveTasks()
+}
+
+func (Local) GetHistoryTasks() ([]model.ActiveTaskResp, error) {
+	return service.GetHistoryTasks()
+}
+
+func (Local) GetTask(id string) (*model.Task, error) {
+	id, err := service.ResolveTaskID(id)
+	if err != nil {
+		return nil, err
+	}
+	return service.GetTask(id)
+}
+
+func (Local) FindTasksByRef(ref string) ([]model.Task, error) {
+	return service.FindTasksByRef(ref)
+}
+
+func (Local) UpdateTask(id string, req model.UpdateTaskReq) (*model.Task, error) {
+	id, err := service.ResolveTaskID(id)
+	if err != nil {
+		return nil, err
+	}
+	return service.UpdateTask(id, req)
+}
+
+func (Local) UpdateProgress(id string, req model.UpdateProgressReq) error {
+	id, err := service.ResolveTaskID(id)
+	if err != nil {
+		return err
+	}
+	return service.UpdateProgress(id, req)
+}
+
+func (Local) DeleteTask(id string) error {
+	id, err := service.ResolveTaskID(id)
+	if err != nil {
+		return err
+	}
+	return service.DeleteTask(id)
+}
+
+func (Local) GetDailySummary(dateStr string, loc *time.Location) (*model.DailySummaryResp, error) {
+	return service.GetDailySummary(dateStr, loc)
+}
+
+func (Local) GetPeriodSummary(fromStr, toStr string, loc *time.Location) (*model.PeriodSummaryResp, error) {
+	return service.GetPeriodSummary(fromStr, toStr, loc)
+}
+
+func (Local) GetStatsSummary(loc *time.Location) (*model.StatsSummaryResp, error) {
+	return service.GetStatsSummary(loc)
+}
+
+func (Local) GetFlowStats(weeks int, category string, loc *time.Location) (*model.FlowStatsResp, error) {
+	return service.GetFlowStats(weeks, category, loc)
+}
+
+func (Local) GetWorklogHeatmap(days int, loc *time.Location) (*model.HeatmapResp, error) {
+	return service.GetWorklogHeatmap(days, loc)
+}
+
+func (Local) GetAgentContext(budget int, loc *time.Location) (*model.AgentContextResp, error) {
+	return service.GetAgentContext(budget, time.Now(), loc)
+}
+
+func (Local) ExportDump() (*model.Dump, error) {
+	return service.ExportDump()
+}
+
+func (Local) ImportDump(dump *model.Dump, mode string) (*model.ImportResult, error) {
+	return service.ImportDump(dump, mode)
+}
+
+func (Local) ExportCSV(req model.CSVExportReq, loc *time.Location) ([]byte, error) {
+	return exporter.ExportCSV(req, loc)
+}
+
+func (Local) ImportCSV(data []byte, kind, mapping string, loc *time.Location) (*model.FileImportResult, error) {
+	columns, err := importer.ParseColumnMapping(mapping)
+	if err != nil {
+		return nil, err
+	}
+	return importer.ImportCSV(bytes.NewReader(data), kind, columns, loc)
+}
+
+func (Local) ImportTaskList(data []byte, format string, req model.TaskListImportReq, loc *time.Location) (*model.FileImportResult, error) {
+	return importer.ImportTaskList(data, format, req, loc)
+}
+
+func (Local) ImportCommits(req model.CommitImportReq) (*model.CommitImportResult, error) {
+	return importer.ImportCommits(req)
+}
+
+func (Local) ExportICS(req model.CalendarReq) ([]byte, error) {
+	return exporter.GenerateICS(req)
+}
+
+func (Local) Export(format string, req model.ExportReq, loc *time.Location) ([]byte, error) {
+	f, err := exporter.Export(format, req, loc)
+	if err != nil {
+		return nil, err
+	}
+	return f.Data, nil
+}
@@ -0,0 +1,363 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yuyudeqiu/chronicle/internal/config"
+	"github.com/yuyudeqiu/chronicle/internal/model"
+)
+
+// Remote runs operations against a Chronicle server's REST API.
+type Remote struct {
+	baseURL string
+	http    *http.Client
+}
+
+// NewRemote creates a client for the server at baseURL, which may include a
+// base path (e.g. https://example.com/chronicle).
+func NewRemote(baseURL string) (*Remote, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid server URL %q", baseURL)
+	}
+
+	return &Remote{
+		baseURL: strings.TrimRight(baseURL, "/") + "/api/v1",
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// envelope mirrors model.StandardResponse with the payload left undecoded.
+type envelope struct {
+	Code int             `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data"`
+}
+
+// do sends body as JSON and decodes the `data` field of the standard response into
+// out (which may be nil).
+func (r *Remote) do(method, path string, query url.Values, body, out interface{}) error {
+	if body == nil {
+		return r.send(method, path, query, "", nil, out)
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return r.send(method, path, query, "application/json", bytes.NewReader(data), out)
+}
+
+// send is do for request bodies that are not JSON.
+func (r *Remote) send(method, path string, query url.Values, contentType string, body io.Reader, out interface{}) error {
+	target := r.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest(method, target, body)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var env envelope
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return fmt.Errorf("unexpected response from server (HTTP %d): %w", resp.StatusCode, err)
+	}
+	if env.Code != 0 {
+		return errors.New(env.Msg)
+	}
+	if out == nil || len(env.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(env.Data, out)
+}
+
+// download fetches an export endpoint, which answers with the file itself rather
+// than the standard response unless it fails.
+func (r *Remote) download(path string, query url.Values) ([]byte, error) {
+	target := r.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	resp, err := r.http.Get(target)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var env envelope
+		if json.Unmarshal(data, &env) == nil && env.Msg != "" {
+			return nil, errors.New(env.Msg)
+		}
+		return nil, fmt.Errorf("unexpected response from server (HTTP %d)", resp.StatusCode)
+	}
+	return data, nil
+}
+
+// tzQuery passes the client's timezone so the server buckets days the same way
+// the local CLI would. The process-local zone is sent by its IANA name; only if that
+// cannot be determined is the server's configured zone used instead.
+func tzQuery(loc *time.Location) url.Values {
+	q := url.Values{}
+	if loc == nil {
+		return q
+	}
+	name := loc.String()
+	if loc == time.Local || name == "Local" {
+		name = config.LocalZoneName()
+	}
+	if name != "" {
+		q.Set("tz", name)
+	}
+	return q
+}
+
+func (r *Remote) CreateTask(req model.CreateTaskReq) (*model.Task, error) {
+	var task model.Task
+	if err := r.do(http.MethodPost, "/tasks", nil, req, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *Remote) GetActiveTasks() ([]model.ActiveTaskResp, error) {
+	var tasks []model.ActiveTaskResp
+	err := r.do(http.MethodGet, "/tasks", nil, nil, &tasks)
+	return tasks, err
+}
+
+func (r *Remote) GetHistoryTasks() ([]model.ActiveTaskResp, error) {
+	var tasks []model.ActiveTaskResp
+	err := r.do(http.MethodGet, "/tasks", url.Values{"status": {model.TaskStatusDone}}, nil, &tasks)
+	return tasks, err
+}
+
+func (r *Remote) GetTask(id string) (*model.Task, error) {
+	var task model.Task
+	if err := r.do(http.MethodGet, "/tasks/"+url.PathEscape(id), nil, nil, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *Remote) FindTasksByRef(ref string) ([]model.Task, error) {
+	var tasks []model.Task
+	err := r.do(http.MethodGet, "/tasks", url.Values{"ref": {ref}}, nil, &tasks)
+	return tasks, err
+}
+
+func (r *Remote) UpdateTask(id string, req model.UpdateTaskReq) (*model.Task, error) {
+	var task model.Task
+	if err := r.do(http.MethodPatch, "/tasks/"+url.PathEscape(id), nil, req, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *Remote) UpdateProgress(id string, req model.UpdateProgressReq) error {
+	// The progress endpoint requires a log text; a bare status change goes through PATCH instead
+	if req.LogText == "" {
+		status := req.NewStatus
+		if req.MarkAsDone {
+			status = model.TaskStatusDone
+		}
+		_, err := r.UpdateTask(id, model.UpdateTaskReq{Status: status, Deadline: req.Deadline})
+		return err
+	}
+	return r.do(http.MethodPost, "/tasks/"+url.PathEscape(id)+"/progress", nil, req, nil)
+}
+
+func (r *Remote) DeleteTask(id string) error {
+	return r.do(http.MethodDelete, "/tasks/"+url.PathEscape(id), nil, nil, nil)
+}
+
+func (r *Remote) GetDailySummary(dateStr string, loc *time.Location) (*model.DailySummaryResp, error) {
+	q := tzQuery(loc)
+	if dateStr != "" {
+		q.Set("date", dateStr)
+	}
+	var summary model.DailySummaryResp
+	if err := r.do(http.MethodGet, "/reports/daily-summary", q, nil, &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+func (r *Remote) GetPeriodSummary(fromStr, toStr string, loc *time.Location) (*model.PeriodSummaryResp, error) {
+	q := tzQuery(loc)
+	if fromStr != "" {
+		q.Set("from", fromStr)
+	}
+	if toStr != "" {
+		q.Set("to", toStr)
+	}
+	var summary model.PeriodSummaryResp
+	if err := r.do(http.MethodGet, "/reports/summary", q, nil, &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+func (r *Remote) GetStatsSummary(loc *time.Location) (*model.StatsSummaryResp, error) {
+	var stats model.StatsSummaryResp
+	if err := r.do(http.MethodGet, "/stats/summary", tzQuery(loc), nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+func (r *Remote) GetFlowStats(weeks int, category string, loc *time.Location) (*model.FlowStatsResp, error) {
+	q := tzQuery(loc)
+	if weeks > 0 {
+		q.Set("weeks", strconv.Itoa(weeks))
+	}
+	if category != "" {
+		q.Set("category", category)
+	}
+	var flow model.FlowStatsResp
+	if err := r.do(http.MethodGet, "/stats/flow", q, nil, &flow); err != nil {
+		return nil, err
+	}
+	return &flow, nil
+}
+
+func (r *Remote) GetWorklogHeatmap(days int, loc *time.Location) (*model.HeatmapResp, error) {
+	q := tzQuery(loc)
+	if days > 0 {
+		q.Set("days", strconv.Itoa(days))
+	}
+	var heatmap model.HeatmapResp
+	if err := r.do(http.MethodGet, "/stats/heatmap", q, nil, &heatmap); err != nil {
+		return nil, err
+	}
+	return &heatmap, nil
+}
+
+func (r *Remote) GetAgentContext(budget int, loc *time.Location) (*model.AgentContextResp, error) {
+	q := tzQuery(loc)
+	q.Set("budget", strconv.Itoa(budget))
+	q.Set("format", "json")
+	var snapshot model.AgentContextResp
+	if err := r.do(http.MethodGet, "/agent/context", q, nil, &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+func (r *Remote) ExportDump() (*model.Dump, error) {
+	data, err := r.download("/exports/full", nil)
+	if err != nil {
+		return nil, err
+	}
+	var dump model.Dump
+	if err := json.Unmarshal(data, &dump); err != nil {
+		return nil, err
+	}
+	return &dump, nil
+}
+
+func (r *Remote) ImportDump(dump *model.Dump, mode string) (*model.ImportResult, error) {
+	var result model.ImportResult
+	if err := r.do(http.MethodPost, "/imports", url.Values{"mode": {mode}}, dump, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (r *Remote) ExportCSV(req model.CSVExportReq, loc *time.Location) ([]byte, error) {
+	q := tzQuery(loc)
+	for key, value := range map[string]string{"type": req.Type, "status": req.Status, "category": req.Category, "from": req.From, "to": req.To} {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+	if req.Archived {
+		q.Set("archived", "true")
+	}
+	return r.download("/exports/csv", q)
+}
+
+func (r *Remote) ImportCSV(data []byte, kind, mapping string, loc *time.Location) (*model.FileImportResult, error) {
+	q := tzQuery(loc)
+	if kind != "" {
+		q.Set("type", kind)
+	}
+	if mapping != "" {
+		q.Set("map", mapping)
+	}
+	var result model.FileImportResult
+	if err := r.send(http.MethodPost, "/imports/csv", q, "text/csv", bytes.NewReader(data), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (r *Remote) ImportTaskList(data []byte, format string, req model.TaskListImportReq, loc *time.Location) (*model.FileImportResult, error) {
+	q := tzQuery(loc)
+	if req.Category != "" {
+		q.Set("category", req.Category)
+	}
+	if req.DryRun {
+		q.Set("dry_run", "true")
+	}
+	var result model.FileImportResult
+	if err := r.send(http.MethodPost, "/imports/"+url.PathEscape(format), q, "text/plain", bytes.NewReader(data), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (r *Remote) ImportCommits(req model.CommitImportReq) (*model.CommitImportResult, error) {
+	var result model.CommitImportResult
+	if err := r.do(http.MethodPost, "/imports/git", nil, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ExportICS fetches the calendar feed with the calendar_token from the local
+// configuration, which has to match the server's.
+func (r *Remote) ExportICS(req model.CalendarReq) ([]byte, error) {
+	q := url.Values{"token": {config.GetCalendarToken()}}
+	if req.Type != "" {
+		q.Set("type", req.Type)
+	}
+	if req.Category != "" {
+		q.Set("category", req.Category)
+	}
+	return r.download("/calendar.ics", q)
+}
+
+func (r *Remote) Export(format string, req model.ExportReq, loc *time.Location) ([]byte, error) {
+	q := tzQuery(loc)
+	for key, value := range map[string]string{"date": req.Date, "from": req.From, "to": req.To, "category": req.Category, "ids": req.IDs, "output": req.Output} {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+	return r.download("/exports/"+url.PathEscape(format), q)
+}
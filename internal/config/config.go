@@ -1,31 +1,34 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 var (
 	// DataDir 用户指定的数据目录
 	DataDir string
+	// Timezone 用户指定的时区 (IANA 名称，如 Asia/Shanghai)，用于按天划分日报、统计与导出
+	Timezone string
+	// TemplatesDir 用户自定义导出模板目录，其中的同名模板会覆盖内置模板
+	TemplatesDir string
+	// ServerURL 远程 Chronicle 服务地址，设置后 CLI 通过 HTTP 调用该服务而非本地数据库
+	ServerURL string
+	// DBDriver 数据库驱动 (sqlite / postgres / mysql)
+	DBDriver string
+	// DBDSN 数据库连接串；SQLite 未指定时使用数据目录下的 app.db
+	DBDSN string
 )
 
 // Load 加载配置
-// 优先级：命令行参数 > 环境变量 > 默认值 (data/)
+// 优先级：命令行参数 > 环境变量 > 配置文件 > 默认值 (data/)
 func Load() string {
-	// 1. 优先使用命令行参数
-	if DataDir != "" {
-		return DataDir
-	}
-
-	// 2. 环境变量
-	envDataDir := os.Getenv("CHRONICLE_DATA_DIR")
-	if envDataDir != "" {
-		return envDataDir
-	}
-
-	// 3. 默认值，保持向后兼容
-	return "data"
+	v, _ := Get("data_dir")
+	return v
 }
 
 // GetDBPath 获取数据库文件路径
@@ -34,8 +37,220 @@ func GetDBPath() string {
 	return filepath.Join(dir, "app.db")
 }
 
+// GetDatabase 获取数据库驱动与连接串
+// 优先级：命令行参数 > 环境变量 > 配置文件 > 默认值 (sqlite，数据目录下的 app.db)
+func GetDatabase() (string, string) {
+	driver, _ := Get("db.driver")
+	dsn, _ := Get("db.dsn")
+	if dsn == "" && driver == "sqlite" {
+		dsn = GetDBPath()
+	}
+	return driver, dsn
+}
+
+// GetSnapshotDir 获取定时快照目录 (数据目录下的 backups/)
+func GetSnapshotDir() string {
+	return filepath.Join(Load(), "backups")
+}
+
 // EnsureDataDir 确保数据目录存在
 func EnsureDataDir() error {
 	dir := Load()
 	return os.MkdirAll(dir, 0755)
 }
+
+// ServerConfig Web 服务配置
+type ServerConfig struct {
+	// Listen 监听地址，如 ":8080" 或 "127.0.0.1:8080"
+	Listen string
+	// TLSCert / TLSKey 证书与私钥文件，同时设置时启用 HTTPS
+	TLSCert string
+	TLSKey  string
+	// BasePath 部署在反向代理子路径下时的路径前缀，如 "/chronicle"
+	BasePath string
+	// ShutdownTimeout 收到退出信号后等待进行中请求完成的最长时间
+	ShutdownTimeout time.Duration
+	// SnapshotDaily / SnapshotWeekly 定时快照保留的每日 / 每周份数，0 表示不生成
+	SnapshotDaily  int
+	SnapshotWeekly int
+	// ObsidianSync 定时同步 Obsidian 仓库的间隔，0 表示不同步
+	ObsidianSync time.Duration
+	// AutoMigrate 启动时自动执行未应用的数据库迁移，否则有未应用的迁移时拒绝启动
+	AutoMigrate bool
+}
+
+// Server 用户指定的 Web 服务配置（命令行参数）
+var Server ServerConfig
+
+// GetServerConfig 获取 Web 服务配置
+// 优先级：命令行参数 > 环境变量 > 配置文件 > 默认值
+func GetServerConfig() (ServerConfig, error) {
+	var cfg ServerConfig
+	cfg.Listen, _ = Get("server.listen")
+	cfg.TLSCert, _ = Get("server.tls_cert")
+	cfg.TLSKey, _ = Get("server.tls_key")
+	cfg.BasePath, _ = Get("server.base_path")
+
+	timeout, source := Get("server.shutdown_timeout")
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		return cfg, fmt.Errorf("invalid shutdown timeout %q (from %s): %w", timeout, source, err)
+	}
+	cfg.ShutdownTimeout = d
+
+	interval, source := Get("server.obsidian_sync")
+	if cfg.ObsidianSync, err = time.ParseDuration(interval); err != nil || cfg.ObsidianSync < 0 {
+		return cfg, fmt.Errorf("invalid obsidian sync interval %q (from %s): must be a non-negative duration", interval, source)
+	}
+
+	autoMigrate, source := Get("server.auto_migrate")
+	if cfg.AutoMigrate, err = strconv.ParseBool(autoMigrate); err != nil {
+		return cfg, fmt.Errorf("invalid auto migrate %q (from %s): must be true or false", autoMigrate, source)
+	}
+
+	for _, n := range []struct {
+		key string
+		dst *int
+	}{
+		{"server.snapshot_daily", &cfg.SnapshotDaily},
+		{"server.snapshot_weekly", &cfg.SnapshotWeekly},
+	} {
+		v, source := Get(n.key)
+		if *n.dst, err = strconv.Atoi(v); err != nil || *n.dst < 0 {
+			return cfg, fmt.Errorf("invalid %s %q (from %s): must be a non-negative integer", n.key, v, source)
+		}
+	}
+
+	if (cfg.TLSCert == "") != (cfg.TLSKey == "") {
+		return cfg, fmt.Errorf("both TLS certificate and key must be set to enable HTTPS")
+	}
+
+	// 统一为 "/xxx" 形式，根路径记为空字符串
+	cfg.BasePath = strings.TrimRight(cfg.BasePath, "/")
+	if cfg.BasePath != "" && !strings.HasPrefix(cfg.BasePath, "/") {
+		cfg.BasePath = "/" + cfg.BasePath
+	}
+
+	return cfg, nil
+}
+
+// ObsidianConfig Obsidian 仓库同步配置
+type ObsidianConfig struct {
+	// Vault Obsidian 仓库根目录
+	Vault string
+	// Folder 任务笔记所在的仓库子目录
+	Folder string
+	// DailyFolder 日记 (Daily Note) 所在的仓库子目录，空表示仓库根目录
+	DailyFolder string
+}
+
+// Obsidian 用户指定的 Obsidian 同步配置（命令行参数）
+var Obsidian ObsidianConfig
+
+// GetObsidianConfig 获取 Obsidian 同步配置
+// 优先级：命令行参数 > 环境变量 > 配置文件 > 默认值 (任务笔记写入 Chronicle/ 子目录)
+func GetObsidianConfig() ObsidianConfig {
+	var cfg ObsidianConfig
+	cfg.Vault, _ = Get("obsidian.vault")
+	cfg.Folder, _ = Get("obsidian.folder")
+	cfg.DailyFolder, _ = Get("obsidian.daily_folder")
+	return cfg
+}
+
+// GetTemplatesDir 获取自定义模板目录
+// 优先级：命令行参数 > 环境变量 > 配置文件 > 默认值 (空，即只使用数据目录下的模板与内置模板)
+func GetTemplatesDir() string {
+	v, _ := Get("templates_dir")
+	return v
+}
+
+// GetUserTemplatesDir 获取数据目录下的自定义模板目录 (templates/)，优先级低于 templates_dir
+func GetUserTemplatesDir() string {
+	return filepath.Join(Load(), "templates")
+}
+
+// GetDefaultCategory 获取创建任务时未指定分类所使用的默认分类
+func GetDefaultCategory() string {
+	v, _ := Get("default_category")
+	return v
+}
+
+// GetOutput 获取 CLI 默认输出格式 (text 或 json)
+func GetOutput() string {
+	v, _ := Get("output")
+	return v
+}
+
+// GetServerURL 获取远程服务地址，空字符串表示直接使用本地数据库
+func GetServerURL() string {
+	v, _ := Get("server_url")
+	return v
+}
+
+// GetCalendarToken 获取日历订阅地址中的访问令牌，空字符串表示不开放日历订阅
+func GetCalendarToken() string {
+	v, _ := Get("calendar_token")
+	return v
+}
+
+// GetTimezone 获取配置的时区名称
+// 优先级：命令行参数 > 环境变量 > 配置文件 > 默认值 (空，即系统本地时区)
+func GetTimezone() string {
+	v, _ := Get("timezone")
+	return v
+}
+
+// GetLocation 获取用于日期边界计算的时区
+func GetLocation() (*time.Location, error) {
+	return LoadLocation(GetTimezone())
+}
+
+// LoadLocation 解析时区名称，空字符串表示系统本地时区
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+// LocalZoneName 返回系统本地时区的 IANA 名称 (依次检查 TZ、/etc/localtime 链接与
+// /etc/timezone)，无法确定时返回空字符串
+func LocalZoneName() string {
+	if tz, ok := os.LookupEnv("TZ"); ok {
+		// 与 Go 运行时一致：TZ 为空或无法解析时使用 UTC
+		tz = strings.TrimPrefix(tz, ":")
+		if tz == "" {
+			return "UTC"
+		}
+		if _, err := time.LoadLocation(tz); err != nil {
+			return "UTC"
+		}
+		return tz
+	}
+
+	if target, err := os.Readlink("/etc/localtime"); err == nil {
+		if i := strings.LastIndex(target, "zoneinfo/"); i >= 0 {
+			if name := target[i+len("zoneinfo/"):]; validZoneName(name) {
+				return name
+			}
+		}
+	}
+	if data, err := os.ReadFile("/etc/timezone"); err == nil {
+		if name := strings.TrimSpace(string(data)); validZoneName(name) {
+			return name
+		}
+	}
+	return ""
+}
+
+func validZoneName(name string) bool {
+	if name == "" || name == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
@@ -0,0 +1,395 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/pelletier/go-toml/v2"
+)
+
+var (
+	// ConfigPath 用户指定的配置文件路径
+	ConfigPath string
+	// Profile 用户指定的配置档案名称 (如 work / personal)
+	Profile string
+)
+
+// Settings 配置文件中可设置的选项，顶层与每个 profile 使用相同结构
+type Settings struct {
+	DataDir         string           `yaml:"data_dir,omitempty" toml:"data_dir,omitempty"`
+	Timezone        string           `yaml:"timezone,omitempty" toml:"timezone,omitempty"`
+	TemplatesDir    string           `yaml:"templates_dir,omitempty" toml:"templates_dir,omitempty"`
+	DefaultCategory string           `yaml:"default_category,omitempty" toml:"default_category,omitempty"`
+	Output          string           `yaml:"output,omitempty" toml:"output,omitempty"`
+	ServerURL       string           `yaml:"server_url,omitempty" toml:"server_url,omitempty"`
+	CalendarToken   string           `yaml:"calendar_token,omitempty" toml:"calendar_token,omitempty"`
+	Server          ServerSettings   `yaml:"server,omitempty" toml:"server,omitempty"`
+	DB              DBSettings       `yaml:"db,omitempty" toml:"db,omitempty"`
+	Obsidian        ObsidianSettings `yaml:"obsidian,omitempty" toml:"obsidian,omitempty"`
+}
+
+// ObsidianSettings 配置文件中的 Obsidian 同步选项
+type ObsidianSettings struct {
+	Vault       string `yaml:"vault,omitempty" toml:"vault,omitempty"`
+	Folder      string `yaml:"folder,omitempty" toml:"folder,omitempty"`
+	DailyFolder string `yaml:"daily_folder,omitempty" toml:"daily_folder,omitempty"`
+}
+
+// DBSettings 配置文件中的数据库选项
+type DBSettings struct {
+	Driver string `yaml:"driver,omitempty" toml:"driver,omitempty"`
+	DSN    string `yaml:"dsn,omitempty" toml:"dsn,omitempty"`
+}
+
+// ServerSettings 配置文件中的 Web 服务选项
+type ServerSettings struct {
+	Listen          string `yaml:"listen,omitempty" toml:"listen,omitempty"`
+	TLSCert         string `yaml:"tls_cert,omitempty" toml:"tls_cert,omitempty"`
+	TLSKey          string `yaml:"tls_key,omitempty" toml:"tls_key,omitempty"`
+	BasePath        string `yaml:"base_path,omitempty" toml:"base_path,omitempty"`
+	ShutdownTimeout string `yaml:"shutdown_timeout,omitempty" toml:"shutdown_timeout,omitempty"`
+	SnapshotDaily   string `yaml:"snapshot_daily,omitempty" toml:"snapshot_daily,omitempty"`
+	SnapshotWeekly  string `yaml:"snapshot_weekly,omitempty" toml:"snapshot_weekly,omitempty"`
+	ObsidianSync    string `yaml:"obsidian_sync,omitempty" toml:"obsidian_sync,omitempty"`
+	AutoMigrate     string `yaml:"auto_migrate,omitempty" toml:"auto_migrate,omitempty"`
+}
+
+// File 配置文件结构
+type File struct {
+	Settings `yaml:",inline"`
+	// Profile 未通过 --profile / CHRONICLE_PROFILE 指定时默认启用的档案
+	Profile  string              `yaml:"profile,omitempty" toml:"profile,omitempty"`
+	Profiles map[string]Settings `yaml:"profiles,omitempty" toml:"profiles,omitempty"`
+}
+
+// setting 描述一个配置项的各个来源
+type setting struct {
+	key   string
+	env   string
+	flag  func() string
+	field func(s *Settings) *string
+	def   string
+	check func(v string) error
+}
+
+var settings = []setting{
+	{key: "data_dir", env: "CHRONICLE_DATA_DIR", flag: func() string { return DataDir }, field: func(s *Settings) *string { return &s.DataDir }, def: "data"},
+	{key: "timezone", env: "CHRONICLE_TZ", flag: func() string { return Timezone }, field: func(s *Settings) *string { return &s.Timezone }, check: checkTimezone},
+	{key: "templates_dir", env: "CHRONICLE_TEMPLATES_DIR", flag: func() string { return TemplatesDir }, field: func(s *Settings) *string { return &s.TemplatesDir }},
+	{key: "default_category", env: "CHRONICLE_DEFAULT_CATEGORY", field: func(s *Settings) *string { return &s.DefaultCategory }},
+	{key: "output", env: "CHRONICLE_OUTPUT", field: func(s *Settings) *string { return &s.Output }, def: "text", check: checkOutput},
+	{key: "server_url", env: "CHRONICLE_SERVER", flag: func() string { return ServerURL }, field: func(s *Settings) *string { return &s.ServerURL }},
+	{key: "calendar_token", env: "CHRONICLE_CALENDAR_TOKEN", field: func(s *Settings) *string { return &s.CalendarToken }},
+	{key: "server.listen", env: "CHRONICLE_LISTEN", flag: func() string { return Server.Listen }, field: func(s *Settings) *string { return &s.Server.Listen }, def: ":8080"},
+	{key: "server.tls_cert", env: "CHRONICLE_TLS_CERT", flag: func() string { return Server.TLSCert }, field: func(s *Settings) *string { return &s.Server.TLSCert }},
+	{key: "server.tls_key", env: "CHRONICLE_TLS_KEY", flag: func() string { return Server.TLSKey }, field: func(s *Settings) *string { return &s.Server.TLSKey }},
+	{key: "server.base_path", env: "CHRONICLE_BASE_PATH", flag: func() string { return Server.BasePath }, field: func(s *Settings) *string { return &s.Server.BasePath }},
+	{key: "server.shutdown_timeout", env: "CHRONICLE_SHUTDOWN_TIMEOUT", flag: durationFlag(&Server.ShutdownTimeout), field: func(s *Settings) *string { return &s.Server.ShutdownTimeout }, def: "10s", check: checkDuration},
+	{key: "server.snapshot_daily", env: "CHRONICLE_SNAPSHOT_DAILY", flag: intFlag(&Server.SnapshotDaily), field: func(s *Settings) *string { return &s.Server.SnapshotDaily }, def: "0", check: checkCount},
+	{key: "server.snapshot_weekly", env: "CHRONICLE_SNAPSHOT_WEEKLY", flag: intFlag(&Server.SnapshotWeekly), field: func(s *Settings) *string { return &s.Server.SnapshotWeekly }, def: "0", check: checkCount},
+	{key: "server.obsidian_sync", env: "CHRONICLE_OBSIDIAN_SYNC", flag: durationFlag(&Server.ObsidianSync), field: func(s *Settings) *string { return &s.Server.ObsidianSync }, def: "0", check: checkDuration},
+	{key: "server.auto_migrate", env: "CHRONICLE_AUTO_MIGRATE", flag: boolFlag(&Server.AutoMigrate), field: func(s *Settings) *string { return &s.Server.AutoMigrate }, def: "false", check: checkBool},
+	{key: "db.driver", env: "CHRONICLE_DB_DRIVER", flag: func() string { return DBDriver }, field: func(s *Settings) *string { return &s.DB.Driver }, def: "sqlite", check: checkDBDriver},
+	{key: "db.dsn", env: "CHRONICLE_DB_DSN", flag: func() string { return DBDSN }, field: func(s *Settings) *string { return &s.DB.DSN }},
+	{key: "obsidian.vault", env: "CHRONICLE_OBSIDIAN_VAULT", flag: func() string { return Obsidian.Vault }, field: func(s *Settings) *string { return &s.Obsidian.Vault }},
+	{key: "obsidian.folder", env: "CHRONICLE_OBSIDIAN_FOLDER", flag: func() string { return Obsidian.Folder }, field: func(s *Settings) *string { return &s.Obsidian.Folder }, def: "Chronicle"},
+	{key: "obsidian.daily_folder", env: "CHRONICLE_OBSIDIAN_DAILY_FOLDER", flag: func() string { return Obsidian.DailyFolder }, field: func(s *Settings) *string { return &s.Obsidian.DailyFolder }},
+}
+
+// ErrProfileNotFound 启用的 profile 在配置文件中不存在
+var ErrProfileNotFound = errors.New("profile not found")
+
+// loaded 已加载的配置文件内容，active 为合并了当前 profile 后的有效配置
+var (
+	loaded *File
+	active Settings
+)
+
+// Keys 返回所有可配置项的名称
+func Keys() []string {
+	keys := make([]string, 0, len(settings))
+	for _, s := range settings {
+		keys = append(keys, s.key)
+	}
+	return keys
+}
+
+// Get 获取配置项的有效值及其来源 (flag / env / file / default)
+// 优先级：命令行参数 > 环境变量 > 配置文件 > 默认值
+func Get(key string) (string, string) {
+	if key == "profile" {
+		return getProfile()
+	}
+
+	s, ok := lookup(key)
+	if !ok {
+		return "", ""
+	}
+	if s.flag != nil {
+		if v := s.flag(); v != "" {
+			return v, "flag"
+		}
+	}
+	if v := os.Getenv(s.env); v != "" {
+		return v, "env " + s.env
+	}
+	if v := *s.field(&active); v != "" {
+		return v, "file"
+	}
+	return s.def, "default"
+}
+
+// Set 将配置项写入配置文件；启用了 profile 时写入该 profile
+// 键 "profile" 设置默认启用的 profile，总是写入顶层。
+func Set(key, value string) error {
+	f := File{}
+	if loaded != nil {
+		f = *loaded
+	}
+
+	if key == "profile" {
+		f.Profile = value
+		if err := writeFile(GetConfigPath(), &f); err != nil {
+			return err
+		}
+		return apply(&f)
+	}
+
+	s, ok := lookup(key)
+	if !ok {
+		return fmt.Errorf("unknown config key %q (available: profile, %s)", key, strings.Join(Keys(), ", "))
+	}
+	if s.check != nil && value != "" {
+		if err := s.check(value); err != nil {
+			return err
+		}
+	}
+
+	if name := ActiveProfile(); name != "" {
+		if f.Profiles == nil {
+			f.Profiles = make(map[string]Settings)
+		}
+		p := f.Profiles[name]
+		*s.field(&p) = value
+		f.Profiles[name] = p
+	} else {
+		*s.field(&f.Settings) = value
+	}
+
+	if err := writeFile(GetConfigPath(), &f); err != nil {
+		return err
+	}
+	return apply(&f)
+}
+
+// GetConfigPath 获取配置文件路径
+// 优先级：命令行参数 > 环境变量 CHRONICLE_CONFIG > ~/.config/chronicle/config.yaml
+func GetConfigPath() string {
+	if ConfigPath != "" {
+		return ConfigPath
+	}
+	if v := os.Getenv("CHRONICLE_CONFIG"); v != "" {
+		return v
+	}
+
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "config.yaml"
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "chronicle", "config.yaml")
+}
+
+func getProfile() (string, string) {
+	if Profile != "" {
+		return Profile, "flag"
+	}
+	if v := os.Getenv("CHRONICLE_PROFILE"); v != "" {
+		return v, "env CHRONICLE_PROFILE"
+	}
+	if loaded != nil && loaded.Profile != "" {
+		return loaded.Profile, "file"
+	}
+	return "", "default"
+}
+
+// ActiveProfile 获取当前启用的 profile
+// 优先级：命令行参数 > 环境变量 CHRONICLE_PROFILE > 配置文件中的 profile
+func ActiveProfile() string {
+	name, _ := getProfile()
+	return name
+}
+
+// LoadFile 读取配置文件。默认路径下的文件不存在时视为空配置，
+// 显式指定的文件不存在则报错。
+func LoadFile() error {
+	path := GetConfigPath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		explicit := ConfigPath != "" || os.Getenv("CHRONICLE_CONFIG") != ""
+		if errors.Is(err, os.ErrNotExist) && !explicit {
+			return apply(&File{})
+		}
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	var f File
+	if isTOML(path) {
+		err = toml.Unmarshal(data, &f)
+	} else {
+		err = yaml.Unmarshal(data, &f)
+	}
+	if err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	return apply(&f)
+}
+
+// apply 记录已加载的配置并合并当前 profile
+func apply(f *File) error {
+	loaded = f
+	active = f.Settings
+
+	name := ActiveProfile()
+	if name == "" {
+		return nil
+	}
+	p, ok := f.Profiles[name]
+	if !ok {
+		return fmt.Errorf("%w: %q in %s", ErrProfileNotFound, name, GetConfigPath())
+	}
+	for _, s := range settings {
+		if v := *s.field(&p); v != "" {
+			*s.field(&active) = v
+		}
+	}
+	return nil
+}
+
+// ProfileNames 返回配置文件中定义的所有 profile
+func ProfileNames() []string {
+	if loaded == nil {
+		return nil
+	}
+	names := make([]string, 0, len(loaded.Profiles))
+	for name := range loaded.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func writeFile(path string, f *File) error {
+	var (
+		data []byte
+		err  error
+	)
+	if isTOML(path) {
+		data, err = toml.Marshal(f)
+	} else {
+		data, err = yaml.Marshal(f)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func isTOML(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".toml")
+}
+
+func lookup(key string) (setting, bool) {
+	for _, s := range settings {
+		if s.key == key {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
+
+// durationFlag reports a duration flag as unset while it is zero.
+func durationFlag(p *time.Duration) func() string {
+	return func() string {
+		if *p == 0 {
+			return ""
+		}
+		return p.String()
+	}
+}
+
+// intFlag reports an int flag as unset while it is zero, like durationFlag.
+func intFlag(p *int) func() string {
+	return func() string {
+		if *p == 0 {
+			return ""
+		}
+		return strconv.Itoa(*p)
+	}
+}
+
+// boolFlag reports a bool flag as unset while it is false, like durationFlag.
+func boolFlag(p *bool) func() string {
+	return func() string {
+		if !*p {
+			return ""
+		}
+		return "true"
+	}
+}
+
+func checkTimezone(v string) error {
+	_, err := LoadLocation(v)
+	return err
+}
+
+func checkOutput(v string) error {
+	if v != "text" && v != "json" {
+		return fmt.Errorf("invalid output %q: must be text or json", v)
+	}
+	return nil
+}
+
+func checkDBDriver(v string) error {
+	switch v {
+	case "sqlite", "postgres", "mysql":
+		return nil
+	}
+	return fmt.Errorf("invalid database driver %q: must be sqlite, postgres or mysql", v)
+}
+
+func checkBool(v string) error {
+	if _, err := strconv.ParseBool(v); err != nil {
+		return fmt.Errorf("invalid value %q: must be true or false", v)
+	}
+	return nil
+}
+
+func checkCount(v string) error {
+	if n, err := strconv.Atoi(v); err != nil || n < 0 {
+		return fmt.Errorf("invalid count %q: must be a non-negative integer", v)
+	}
+	return nil
+}
+
+func checkDuration(v string) error {
+	if _, err := time.ParseDuration(v); err != nil {
+		return fmt.Errorf("invalid duration %q: %w", v, err)
+	}
+	return nil
+}
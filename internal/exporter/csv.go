@@ -0,0 +1,109 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yuyudeqiu/chronicle/internal/model"
+	"github.com/yuyudeqiu/chronicle/internal/service"
+)
+
+// ErrInvalidOptions is returned for export options that cannot be satisfied.
+var ErrInvalidOptions = errors.New("invalid export options")
+
+// CSVTimeLayout is used for all timestamps in CSV files; spreadsheets parse it as a
+// date-time. The importer also accepts RFC 3339 and plain dates.
+const CSVTimeLayout = "2006-01-02 15:04:05"
+
+// TaskCSVColumns and WorklogCSVColumns are the headers of exported CSV files, and
+// the field names a column mapping refers to on import.
+var (
+	TaskCSVColumns    = []string{"id", "title", "category", "status", "description", "targets", "links", "external_ref", "deadline", "actual_completed_at", "archived_at", "created_at", "updated_at"}
+	WorklogCSVColumns = []string{"id", "task_id", "task_title", "category", "log_text", "progress_note", "created_at"}
+)
+
+// utf8BOM lets Excel detect the encoding, which it otherwise guesses wrong for
+// non-ASCII text.
+const utf8BOM = "\ufeff"
+
+// ExportCSV renders tasks or worklogs as CSV. Timestamps are written in loc.
+func ExportCSV(req model.CSVExportReq, loc *time.Location) ([]byte, error) {
+	var rows [][]string
+	switch req.Type {
+	case "", model.CSVTypeTasks:
+		statuses, err := parseStatuses(req.Status)
+		if err != nil {
+			return nil, err
+		}
+		tasks, err := service.FindTasks(statuses, req.Category, req.Archived)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, TaskCSVColumns)
+		for _, t := range tasks {
+			rows = append(rows, []string{
+				t.ID, t.Title, t.Category, t.Status, t.Description, t.Targets, t.Links.String(), t.ExternalRef,
+				csvTime(t.Deadline, loc), csvTime(t.ActualCompletedAt, loc), csvTime(t.ArchivedAt, loc),
+				csvTime(&t.CreatedAt, loc), csvTime(&t.UpdatedAt, loc),
+			})
+		}
+	case model.CSVTypeWorklogs:
+		logs, err := service.FindWorklogs(req.From, req.To, loc)
+		if err != nil {
+			return nil, err
+		}
+		var ids []string
+		for _, l := range logs {
+			ids = append(ids, l.TaskID)
+		}
+		tasks, err := service.GetTasksByID(ids)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, WorklogCSVColumns)
+		for _, l := range logs {
+			t := tasks[l.TaskID]
+			rows = append(rows, []string{
+				l.ID, l.TaskID, t.Title, t.Category, l.LogText, l.ProgressNote, csvTime(&l.CreatedAt, loc),
+			})
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported type %q (expected %s or %s)", ErrInvalidOptions, req.Type, model.CSVTypeTasks, model.CSVTypeWorklogs)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(utf8BOM)
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func parseStatuses(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var statuses []string
+	for _, status := range strings.Split(s, ",") {
+		status = strings.TrimSpace(status)
+		switch status {
+		case model.TaskStatusTodo, model.TaskStatusInProgress, model.TaskStatusDone:
+			statuses = append(statuses, status)
+		default:
+			return nil, fmt.Errorf("%w: unsupported status %q", ErrInvalidOptions, status)
+		}
+	}
+	return statuses, nil
+}
+
+func csvTime(t *time.Time, loc *time.Location) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.In(loc).Format(CSVTimeLayout)
+}
@@ -0,0 +1,166 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yuyudeqiu/chronicle/internal/model"
+	"github.com/yuyudeqiu/chronicle/internal/service"
+)
+
+// ErrUnknownFormat is returned by Export for a format that is not registered.
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// Format renders tasks in one file format. Formats register themselves with
+// Register and are selected by name in GET /api/v1/exports/:format and
+// "chronicle export <format>".
+type Format interface {
+	// Name is the format name used in URLs and on the command line.
+	Name() string
+	// Description is a one-line summary shown in format listings.
+	Description() string
+	// Ext is the file extension, including the dot.
+	Ext() string
+	ContentType() string
+	// Zip reports whether the format writes one file per task unless asked otherwise,
+	// as for notes that belong in separate files.
+	Zip() bool
+	// Render writes the tasks of d, with their worklogs and status changes, as one
+	// document. Times are rendered in loc.
+	Render(w io.Writer, d *model.Dump, loc *time.Location) error
+}
+
+var formats = make(map[string]Format)
+
+// Register makes a format available by its name. It panics when the name is taken.
+func Register(f Format) {
+	if _, ok := formats[f.Name()]; ok {
+		panic("exporter: format " + f.Name() + " registered twice")
+	}
+	formats[f.Name()] = f
+}
+
+// Lookup returns the format registered under name.
+func Lookup(name string) (Format, bool) {
+	f, ok := formats[name]
+	return f, ok
+}
+
+// Formats returns all registered formats sorted by name.
+func Formats() []Format {
+	list := make([]Format, 0, len(formats))
+	for _, f := range formats {
+		list = append(list, f)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list
+}
+
+// FormatNames returns the names of all registered formats, sorted.
+func FormatNames() []string {
+	var names []string
+	for _, f := range Formats() {
+		names = append(names, f.Name())
+	}
+	return names
+}
+
+// File is a rendered export, ready to be downloaded or written to disk.
+type File struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Export renders the tasks selected by req in the named format, either as one
+// document or as a zip archive of one file per task.
+func Export(format string, req model.ExportReq, loc *time.Location) (*File, error) {
+	f, ok := Lookup(format)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q (available: %s)", ErrUnknownFormat, format, strings.Join(FormatNames(), ", "))
+	}
+
+	asZip := f.Zip()
+	switch req.Output {
+	case "":
+	case model.ExportOutputFile:
+		asZip = false
+	case model.ExportOutputZip:
+		asZip = true
+	default:
+		return nil, fmt.Errorf("%w: unsupported output %q (expected %s or %s)", ErrInvalidOptions, req.Output, model.ExportOutputFile, model.ExportOutputZip)
+	}
+
+	d, err := service.ExportSelection(req, loc)
+	if errors.Is(err, service.ErrInvalidFilter) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidOptions, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !asZip {
+		var buf bytes.Buffer
+		if err := render(f, &buf, d, loc); err != nil {
+			return nil, err
+		}
+		return &File{Name: "chronicle-" + f.Name() + f.Ext(), ContentType: f.ContentType(), Data: buf.Bytes()}, nil
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, t := range d.Tasks {
+		w, err := zw.Create(taskFileName(t, f.Ext()))
+		if err != nil {
+			return nil, err
+		}
+		if err := render(f, w, taskDump(d, t), loc); err != nil {
+			return nil, fmt.Errorf("render task %s: %w", t.ID, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return &File{Name: "chronicle-" + f.Name() + ".zip", ContentType: "application/zip", Data: buf.Bytes()}, nil
+}
+
+// taskDump returns the part of d that belongs to task t.
+func taskDump(d *model.Dump, t model.Task) *model.Dump {
+	sub := *d
+	sub.Tasks = []model.Task{t}
+	sub.Logs = []model.TaskLog{}
+	for _, l := range d.Logs {
+		if l.TaskID == t.ID {
+			sub.Logs = append(sub.Logs, l)
+		}
+	}
+	sub.StatusChanges = []model.TaskStatusChange{}
+	for _, c := range d.StatusChanges {
+		if c.TaskID == t.ID {
+			sub.StatusChanges = append(sub.StatusChanges, c)
+		}
+	}
+	return &sub
+}
+
+// taskViews prepares the tasks of d for templates, with each task's logs newest first.
+func taskViews(d *model.Dump, loc *time.Location) []TaskView {
+	logsByTask := make(map[string][]model.TaskLog)
+	for _, l := range d.Logs {
+		logsByTask[l.TaskID] = append(logsByTask[l.TaskID], l)
+	}
+
+	views := make([]TaskView, 0, len(d.Tasks))
+	for _, t := range d.Tasks {
+		logs := logsByTask[t.ID]
+		sort.SliceStable(logs, func(i, j int) bool { return logs[i].CreatedAt.After(logs[j].CreatedAt) })
+		views = append(views, newTaskView(t, logs, loc))
+	}
+	return views
+}
@@ -0,0 +1,137 @@
+package exporter
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/yuyudeqiu/chronicle/internal/model"
+)
+
+func init() {
+	Register(&templateFormat{
+		name:        "obsidian",
+		description: "Obsidian notes with front matter, one per task",
+		ext:         ".md",
+		contentType: "text/markdown; charset=utf-8",
+		template:    "obsidian_task.tmpl",
+		zip:         true,
+		perTask:     true,
+	})
+	Register(&templateFormat{
+		name:        "markdown",
+		description: "Plain Markdown document",
+		ext:         ".md",
+		contentType: "text/markdown; charset=utf-8",
+		template:    "markdown.tmpl",
+	})
+	Register(&templateFormat{
+		name:        "html",
+		description: "Self-contained HTML page",
+		ext:         ".html",
+		contentType: "text/html; charset=utf-8",
+		template:    "html.tmpl",
+		html:        true,
+	})
+	Register(jsonFormat{})
+	Register(logseqFormat{})
+	Register(orgFormat{})
+}
+
+// DocumentView is the data of templates that render several tasks into one document.
+type DocumentView struct {
+	Tasks      []TaskView
+	ExportedAt string
+	// Exported is ExportedAt as a time, for the date helper.
+	Exported time.Time
+}
+
+func newDocumentView(d *model.Dump, loc *time.Location) DocumentView {
+	exported := d.ExportedAt.In(loc)
+	return DocumentView{Tasks: taskViews(d, loc), ExportedAt: exported.Format("2006-01-02 15:04:05"), Exported: exported}
+}
+
+// render renders d with f, or with the user's "<format>.tmpl" for formats rendered
+// in Go. Such templates get a DocumentView.
+func render(f Format, w io.Writer, d *model.Dump, loc *time.Location) error {
+	if _, ok := f.(*templateFormat); !ok {
+		if path := findTemplate(templateName(f)); path != "" {
+			tmpl, err := parseTemplate(templateName(f), path)
+			if err != nil {
+				return err
+			}
+			return tmpl.Execute(w, newDocumentView(d, loc))
+		}
+	}
+	return f.Render(w, d, loc)
+}
+
+// templater is satisfied by both text and HTML templates.
+type templater interface {
+	Execute(w io.Writer, data any) error
+}
+
+// templateFormat renders a Go template, which the templates directory can override.
+type templateFormat struct {
+	name        string
+	description string
+	ext         string
+	contentType string
+	template    string
+	zip         bool
+	// perTask executes the template once per task with a TaskView, instead of once
+	// with a DocumentView.
+	perTask bool
+	// html parses the template with html/template.
+	html bool
+}
+
+func (f *templateFormat) Name() string        { return f.name }
+func (f *templateFormat) Description() string { return f.description }
+func (f *templateFormat) Ext() string         { return f.ext }
+func (f *templateFormat) ContentType() string { return f.contentType }
+func (f *templateFormat) Zip() bool           { return f.zip }
+
+func (f *templateFormat) Render(w io.Writer, d *model.Dump, loc *time.Location) error {
+	var tmpl templater
+	var err error
+	if f.html {
+		tmpl, err = loadHTMLTemplate(f.template)
+	} else {
+		tmpl, err = loadTemplate(f.template)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !f.perTask {
+		return tmpl.Execute(w, newDocumentView(d, loc))
+	}
+	for i, v := range taskViews(d, loc) {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		if err := tmpl.Execute(w, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonFormat writes a chronicle dump, which "chronicle import" accepts. Without
+// filters it is the same as GET /exports/full.
+type jsonFormat struct{}
+
+func (jsonFormat) Name() string        { return "json" }
+func (jsonFormat) Description() string { return "Chronicle dump, importable with chronicle import" }
+func (jsonFormat) Ext() string         { return ".json" }
+func (jsonFormat) ContentType() string { return "application/json; charset=utf-8" }
+func (jsonFormat) Zip() bool           { return false }
+
+func (jsonFormat) Render(w io.Writer, d *model.Dump, loc *time.Location) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
@@ -0,0 +1,114 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yuyudeqiu/chronicle/internal/model"
+	"github.com/yuyudeqiu/chronicle/internal/service"
+)
+
+const icsTimeLayout = "20060102T150405Z"
+
+// icsStatus maps task statuses to VTODO STATUS values.
+var icsStatus = map[string]string{
+	model.TaskStatusTodo:       "NEEDS-ACTION",
+	model.TaskStatusInProgress: "IN-PROCESS",
+	model.TaskStatusDone:       "COMPLETED",
+}
+
+// GenerateICS renders every open, unarchived task with a deadline as an iCalendar
+// VEVENT or VTODO. UIDs are derived from the task ID, so calendar apps update
+// entries in place when a deadline moves.
+func GenerateICS(req model.CalendarReq) ([]byte, error) {
+	component := "VEVENT"
+	switch req.Type {
+	case "", model.CalendarTypeEvent:
+	case model.CalendarTypeTodo:
+		component = "VTODO"
+	default:
+		return nil, fmt.Errorf("%w: unsupported calendar type %q (expected %s or %s)", ErrInvalidOptions, req.Type, model.CalendarTypeEvent, model.CalendarTypeTodo)
+	}
+
+	tasks, err := service.FindTasks([]string{model.TaskStatusTodo, model.TaskStatusInProgress}, req.Category, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	line := func(name, value string) {
+		writeICSLine(&buf, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//chronicle//chronicle//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("X-WR-CALNAME", "Chronicle")
+	for _, t := range tasks {
+		if t.Deadline == nil {
+			continue
+		}
+		deadline := t.Deadline.UTC().Format(icsTimeLayout)
+
+		line("BEGIN", component)
+		line("UID", t.ID+"@chronicle")
+		line("DTSTAMP", t.UpdatedAt.UTC().Format(icsTimeLayout))
+		line("LAST-MODIFIED", t.UpdatedAt.UTC().Format(icsTimeLayout))
+		line("SUMMARY", escapeICSText(t.Title))
+		if desc := icsDescription(t); desc != "" {
+			line("DESCRIPTION", escapeICSText(desc))
+		}
+		line("CATEGORIES", escapeICSText(t.Category))
+		if component == "VTODO" {
+			line("DUE", deadline)
+			line("STATUS", icsStatus[t.Status])
+		} else {
+			// Without DTEND a timed event is a point in time: the deadline itself
+			line("DTSTART", deadline)
+			line("TRANSP", "TRANSPARENT")
+		}
+		line("END", component)
+	}
+	line("END", "VCALENDAR")
+
+	return buf.Bytes(), nil
+}
+
+func icsDescription(t model.Task) string {
+	var parts []string
+	if t.Description != "" {
+		parts = append(parts, t.Description)
+	}
+	if t.Targets != "" {
+		parts = append(parts, "目标: "+t.Targets)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// escapeICSText escapes a TEXT value (RFC 5545 section 3.3.11).
+func escapeICSText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// writeICSLine writes a content line terminated by CRLF, folded so that no line
+// exceeds 75 octets without splitting a UTF-8 sequence (RFC 5545 section 3.1).
+func writeICSLine(buf *bytes.Buffer, s string) {
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		buf.WriteString(s[:cut])
+		buf.WriteString("\r\n ")
+		s = s[cut:]
+		// Continuation lines start with a space, which counts towards the limit
+		limit = 74
+	}
+	buf.WriteString(s)
+	buf.WriteString("\r\n")
+}
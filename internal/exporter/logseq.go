@@ -0,0 +1,95 @@
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yuyudeqiu/chronicle/internal/model"
+)
+
+// logseqFormat writes Logseq outlines: every task is a top-level block with
+// properties, and its sections and worklogs are nested blocks. As a zip, each task
+// becomes its own page.
+type logseqFormat struct{}
+
+func (logseqFormat) Name() string        { return "logseq" }
+func (logseqFormat) Description() string { return "Logseq pages, one per task" }
+func (logseqFormat) Ext() string         { return ".md" }
+func (logseqFormat) ContentType() string { return "text/markdown; charset=utf-8" }
+func (logseqFormat) Zip() bool           { return true }
+
+func (logseqFormat) Render(w io.Writer, d *model.Dump, loc *time.Location) error {
+	b := bufio.NewWriter(w)
+
+	logsByTask := make(map[string][]model.TaskLog)
+	for _, l := range d.Logs {
+		logsByTask[l.TaskID] = append(logsByTask[l.TaskID], l)
+	}
+
+	for _, t := range d.Tasks {
+		logseqBlock(b, 0, t.Title)
+		properties := [][2]string{
+			{"category", t.Category},
+			{"status", t.Status},
+			{"chronicle-id", t.ID},
+			{"created", t.CreatedAt.In(loc).Format("2006-01-02 15:04")},
+		}
+		if t.Deadline != nil {
+			properties = append(properties, [2]string{"deadline", t.Deadline.In(loc).Format("2006-01-02 15:04")})
+		}
+		if t.ActualCompletedAt != nil {
+			properties = append(properties, [2]string{"completed", t.ActualCompletedAt.In(loc).Format("2006-01-02 15:04")})
+		}
+		for _, p := range properties {
+			fmt.Fprintf(b, "  %s:: %s\n", p[0], strings.ReplaceAll(p[1], "\n", " "))
+		}
+
+		for _, section := range []struct{ title, text string }{
+			{"任务描述", t.Description},
+			{"目标", t.Targets},
+			{"相关链接", t.Links.String()},
+		} {
+			if strings.TrimSpace(section.text) == "" {
+				continue
+			}
+			logseqBlock(b, 1, section.title)
+			logseqBlock(b, 2, strings.TrimRight(section.text, "\n"))
+		}
+
+		logs := logsByTask[t.ID]
+		if len(logs) == 0 {
+			continue
+		}
+		sort.SliceStable(logs, func(i, j int) bool { return logs[i].CreatedAt.Before(logs[j].CreatedAt) })
+		logseqBlock(b, 1, "工作记录")
+		day := ""
+		for _, l := range logs {
+			at := l.CreatedAt.In(loc)
+			if d := at.Format("2006-01-02"); d != day {
+				day = d
+				logseqBlock(b, 2, day)
+			}
+			text := at.Format("15:04") + " " + l.LogText
+			if l.ProgressNote != "" {
+				text += " *" + l.ProgressNote + "*"
+			}
+			logseqBlock(b, 3, text)
+		}
+	}
+	return b.Flush()
+}
+
+// logseqBlock writes a block at the given depth. Continuation lines of multi-line
+// text are indented to stay inside the block.
+func logseqBlock(b *bufio.Writer, depth int, text string) {
+	indent := strings.Repeat("  ", depth)
+	lines := strings.Split(text, "\n")
+	fmt.Fprintf(b, "%s- %s\n", indent, lines[0])
+	for _, line := range lines[1:] {
+		fmt.Fprintf(b, "%s  %s\n", indent, line)
+	}
+}
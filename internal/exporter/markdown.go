@@ -1,133 +1,180 @@
 package exporter
 
 import (
-	"archive/zip"
-	"bytes"
-	"fmt"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
-	"text/template"
 	"time"
 
 	"github.com/yuyudeqiu/chronicle/internal/model"
-	"github.com/yuyudeqiu/chronicle/internal/service"
 )
 
+// LogView is a worklog as seen by templates. Time is preformatted ("15:04"); At
+// is the raw time in the export's time zone, for the date helper.
 type LogView struct {
 	Time string
 	Text string
 	Note string
+	At   time.Time
 }
 
+// SubtaskView is a Markdown checklist item ("- [ ] ..." or "- [x] ...") of a task's
+// targets.
+type SubtaskView struct {
+	Text string
+	Done bool
+}
+
+// TaskView is the data of per-task templates. The string fields are preformatted
+// for backwards compatibility; Task holds the raw task with all times in the
+// export's time zone.
 type TaskView struct {
+	ID                 string
 	Title              string
 	Category           string
 	Status             string
 	Description        string
 	Targets            string
-	Links              string
+	Links              model.TaskLinks
 	CreatedAt          string
 	CompletedAt        string
 	Deadline           string
 	LogsByDate         map[string][]LogView
 	ReverseSortedDates []string
+
+	Task model.Task
+	// Logs holds all worklogs, oldest first.
+	Logs     []LogView
+	Subtasks []SubtaskView
+	// Progress is a percentage: 100 once done, otherwise the share of checked
+	// subtasks, or else the latest percentage noted in a worklog (e.g. "60%").
+	Progress int
 }
 
-func GenerateDailyMarkdown(dateStr string) ([]byte, error) {
-	var targetDate time.Time
-	var err error
-	if dateStr == "" {
-		targetDate = time.Now()
-	} else {
-		targetDate, err = time.Parse("2006-01-02", dateStr)
-		if err != nil {
-			return nil, err
-		}
-	}
+// percentPattern finds progress percentages such as "60%" in worklogs.
+var percentPattern = regexp.MustCompile(`(\d{1,3})\s*%`)
 
-	startOfDay := time.Date(targetDate.Year(), targetDate.Month(), targetDate.Day(), 0, 0, 0, 0, targetDate.Location())
-	endOfDay := startOfDay.Add(24 * time.Hour).Add(-time.Nanosecond)
+// checklistPattern matches Markdown checklist items.
+var checklistPattern = regexp.MustCompile(`^\s*[-*+]\s+\[([ xX])\]\s+(.*)$`)
 
-	// query tasks completed today
-	var tasks []model.Task
-	if err := service.DB.Where("status = ? AND actual_completed_at >= ? AND actual_completed_at <= ?", model.TaskStatusDone, startOfDay, endOfDay).Find(&tasks).Error; err != nil {
-		return nil, err
+// GenerateDailyMarkdown zips one Obsidian note per task completed on dateStr
+// (YYYY-MM-DD, default today). Day boundaries and rendered times use loc.
+func GenerateDailyMarkdown(dateStr string, loc *time.Location) ([]byte, error) {
+	if dateStr == "" {
+		dateStr = time.Now().In(loc).Format("2006-01-02")
 	}
-
-	tmpl, err := template.ParseFiles("templates/obsidian_task.tmpl")
+	f, err := Export("obsidian", model.ExportReq{Date: dateStr, Output: model.ExportOutputZip}, loc)
 	if err != nil {
 		return nil, err
 	}
+	return f.Data, nil
+}
 
-	// Create a zip buffer
-	var zipBuf bytes.Buffer
-	zipWriter := zip.NewWriter(&zipBuf)
-
-	for _, t := range tasks {
-		var logs []model.TaskLog
-		if err := service.DB.Where("task_id = ?", t.ID).Order("created_at desc").Find(&logs).Error; err != nil {
-			return nil, err
+// newTaskView prepares a task and its logs (newest first) for the note template.
+// Times are rendered in loc.
+func newTaskView(t model.Task, logs []model.TaskLog, loc *time.Location) TaskView {
+	logsByDate := make(map[string][]LogView)
+	chronological := make([]LogView, len(logs))
+	for i, l := range logs {
+		at := l.CreatedAt.In(loc)
+		v := LogView{
+			Time: at.Format("15:04"),
+			Text: l.LogText,
+			Note: l.ProgressNote,
+			At:   at,
 		}
+		d := at.Format("2006-01-02")
+		logsByDate[d] = append(logsByDate[d], v)
+		chronological[len(logs)-1-i] = v
+	}
 
-		logsByDate := make(map[string][]LogView)
-		for _, l := range logs {
-			d := l.CreatedAt.Format("2006-01-02")
-			logsByDate[d] = append(logsByDate[d], LogView{
-				Time: l.CreatedAt.Format("15:04"),
-				Text: l.LogText,
-				Note: l.ProgressNote,
-			})
-		}
+	completedAt := ""
+	if t.ActualCompletedAt != nil {
+		completedAt = t.ActualCompletedAt.In(loc).Format("2006-01-02 15:04:05")
+	}
 
-		completedAt := ""
-		if t.ActualCompletedAt != nil {
-			completedAt = t.ActualCompletedAt.Format("2006-01-02 15:04:05")
-		}
+	deadlineAt := ""
+	if t.Deadline != nil {
+		deadlineAt = t.Deadline.In(loc).Format("2006-01-02 15:04:05")
+	}
 
-		deadlineAt := ""
-		if t.Deadline != nil {
-			deadlineAt = t.Deadline.Format("2006-01-02 15:04:05")
-		}
+	tv := TaskView{
+		ID:          t.ID,
+		Title:       t.Title,
+		Category:    t.Category,
+		Status:      t.Status,
+		Description: t.Description,
+		Targets:     t.Targets,
+		Links:       t.Links,
+		CreatedAt:   t.CreatedAt.In(loc).Format("2006-01-02 15:04:05"),
+		CompletedAt: completedAt,
+		Deadline:    deadlineAt,
+		LogsByDate:  logsByDate,
+		Task:        localTask(t, loc),
+		Logs:        chronological,
+		Subtasks:    parseSubtasks(t.Targets),
+	}
+	tv.Progress = taskProgress(t.Status, tv.Subtasks, logs)
 
-		tv := TaskView{
-			Title:       t.Title,
-			Category:    t.Category,
-			Status:      t.Status,
-			Description: t.Description,
-			Targets:     t.Targets,
-			Links:       t.Links,
-			CreatedAt:   t.CreatedAt.Format("2006-01-02 15:04:05"),
-			CompletedAt: completedAt,
-			Deadline:    deadlineAt,
-			LogsByDate:  logsByDate,
-		}
+	var dates []string
+	for k := range logsByDate {
+		dates = append(dates, k)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(dates)))
+	tv.ReverseSortedDates = dates
+	return tv
+}
 
-		var dates []string
-		for k := range logsByDate {
-			dates = append(dates, k)
+// localTask returns a copy of t with all times in loc.
+func localTask(t model.Task, loc *time.Location) model.Task {
+	in := func(p *time.Time) *time.Time {
+		if p == nil {
+			return nil
 		}
-		sort.Sort(sort.Reverse(sort.StringSlice(dates)))
-		tv.ReverseSortedDates = dates
+		v := p.In(loc)
+		return &v
+	}
+	t.Deadline = in(t.Deadline)
+	t.ActualCompletedAt = in(t.ActualCompletedAt)
+	t.ArchivedAt = in(t.ArchivedAt)
+	t.CreatedAt = t.CreatedAt.In(loc)
+	t.UpdatedAt = t.UpdatedAt.In(loc)
+	t.Logs = nil
+	return t
+}
 
-		var taskBuf bytes.Buffer
-		if err := tmpl.Execute(&taskBuf, tv); err != nil {
-			continue
+func parseSubtasks(targets string) []SubtaskView {
+	var subtasks []SubtaskView
+	for _, line := range strings.Split(targets, "\n") {
+		if m := checklistPattern.FindStringSubmatch(line); m != nil {
+			subtasks = append(subtasks, SubtaskView{Text: strings.TrimSpace(m[2]), Done: m[1] != " "})
 		}
+	}
+	return subtasks
+}
 
-		safeTitle := strings.ReplaceAll(t.Title, "/", "-")
-		safeTitle = strings.ReplaceAll(safeTitle, "\\", "-")
-		fWriter, err := zipWriter.Create(fmt.Sprintf("%s.md", safeTitle))
-		if err != nil {
-			continue
-		}
-		if _, err := fWriter.Write(taskBuf.Bytes()); err != nil {
-			continue
+// taskProgress derives a completion percentage; logs are newest first.
+func taskProgress(status string, subtasks []SubtaskView, logs []model.TaskLog) int {
+	if status == model.TaskStatusDone {
+		return 100
+	}
+	if len(subtasks) > 0 {
+		done := 0
+		for _, s := range subtasks {
+			if s.Done {
+				done++
+			}
 		}
+		return done * 100 / len(subtasks)
 	}
-
-	if err := zipWriter.Close(); err != nil {
-		return nil, err
+	for _, l := range logs {
+		for _, text := range []string{l.ProgressNote, l.LogText} {
+			if m := percentPattern.FindStringSubmatch(text); m != nil {
+				p, _ := strconv.Atoi(m[1])
+				return min(p, 100)
+			}
+		}
 	}
-
-	return zipBuf.Bytes(), nil
+	return 0
 }
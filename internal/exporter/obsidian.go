@@ -0,0 +1,314 @@
+package exporter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yuyudeqiu/chronicle/internal/model"
+)
+
+// remarksHeading starts the part of a task note that belongs to the user. Everything
+// below it is kept when the note is rendered again.
+const remarksHeading = "## 📝 备注"
+
+// The chronicle section of a daily note is delimited by these markers and replaced
+// on every sync; the rest of the note is left alone.
+const (
+	dailyBlockStart = "<!-- chronicle:start -->"
+	dailyBlockEnd   = "<!-- chronicle:end -->"
+)
+
+// noteIDPattern extracts the ID suffix from a task note's file name.
+var noteIDPattern = regexp.MustCompile(`\(([^()]+)\)\.md$`)
+
+// VaultOptions configures SyncVault.
+type VaultOptions struct {
+	// Vault is the root directory of an existing Obsidian vault.
+	Vault string
+	// Folder holds the task notes, relative to the vault.
+	Folder string
+	// DailyFolder holds the daily notes (named YYYY-MM-DD.md), relative to the vault.
+	DailyFolder string
+	// Days is the number of days up to and including Now whose daily notes are updated.
+	Days int
+	Now  time.Time
+}
+
+// SyncVault writes one note per task of the dump into the vault and adds each day's
+// worklogs to the daily note. Notes are named "<title> (<short id>).md" and found
+// again by the ID, so they are renamed when a title changes. Text below the 备注
+// heading is kept, and notes are only written when their content changed. Notes of
+// tasks that no longer exist are left in place.
+func SyncVault(d *model.Dump, opts VaultOptions, loc *time.Location) (*model.VaultSyncResult, error) {
+	if opts.Vault == "" {
+		return nil, fmt.Errorf("%w: no vault given (set obsidian.vault or use --vault)", ErrInvalidOptions)
+	}
+	if info, err := os.Stat(opts.Vault); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%w: vault %s is not a directory", ErrInvalidOptions, opts.Vault)
+	}
+
+	tmpl, err := loadTemplate("obsidian_task.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	folder := filepath.Join(opts.Vault, opts.Folder)
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return nil, err
+	}
+	existing, err := indexNotes(folder)
+	if err != nil {
+		return nil, err
+	}
+
+	views := taskViews(d, loc)
+	result := &model.VaultSyncResult{Vault: opts.Vault, Created: []string{}, Updated: []string{}, Renamed: []string{}, DailyNotes: []string{}}
+	noteNames := make(map[string]string)
+	for i, t := range d.Tasks {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, views[i]); err != nil {
+			return nil, fmt.Errorf("render note for task %s: %w", t.ID, err)
+		}
+
+		name := noteName(t)
+		noteNames[t.ID] = strings.TrimSuffix(name, ".md")
+		path := filepath.Join(folder, name)
+		rel := filepath.Join(opts.Folder, name)
+
+		// Pick up the note under its old name if the title changed
+		if old, ok := existing[shortNoteID(t.ID)]; ok && old != name {
+			if err := os.Rename(filepath.Join(folder, old), path); err != nil {
+				return nil, err
+			}
+			result.Renamed = append(result.Renamed, rel)
+		}
+
+		current, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		content := keepRemarks(buf.Bytes(), current)
+		if current != nil && bytes.Equal(content, current) {
+			result.Unchanged++
+			continue
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			return nil, err
+		}
+		if current == nil {
+			result.Created = append(result.Created, rel)
+		} else {
+			result.Updated = append(result.Updated, rel)
+		}
+	}
+
+	written, err := syncDailyNotes(d, noteNames, opts, loc)
+	if err != nil {
+		return nil, err
+	}
+	result.DailyNotes = append(result.DailyNotes, written...)
+	return result, nil
+}
+
+// noteName returns the file name of a task's note.
+func noteName(t model.Task) string {
+	return taskFileName(t, ".md")
+}
+
+// taskFileName returns "<title> (<short id>)<ext>". The title is made safe for file
+// systems and for Obsidian, which does not allow links to names containing []#^|.
+func taskFileName(t model.Task, ext string) string {
+	title := strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|', '#', '^', '[', ']', '(', ')':
+			return '-'
+		}
+		if r < ' ' {
+			return -1
+		}
+		return r
+	}, t.Title)
+	title = strings.Trim(title, " .")
+	if runes := []rune(title); len(runes) > 80 {
+		title = strings.TrimSpace(string(runes[:80]))
+	}
+	if title == "" {
+		title = "untitled"
+	}
+	return fmt.Sprintf("%s (%s)%s", title, shortNoteID(t.ID), ext)
+}
+
+// shortNoteID keeps note names short: the first 8 characters of a UUID are unique
+// in practice.
+func shortNoteID(id string) string {
+	id = strings.NewReplacer("/", "-", "\\", "-", "(", "-", ")", "-").Replace(id)
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// indexNotes maps the short task IDs of the notes in folder to their file names.
+func indexNotes(folder string) (map[string]string, error) {
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil, err
+	}
+	notes := make(map[string]string)
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if m := noteIDPattern.FindStringSubmatch(e.Name()); m != nil {
+			notes[m[1]] = e.Name()
+		}
+	}
+	return notes, nil
+}
+
+// keepRemarks appends what follows the 备注 heading in the current note to the
+// rendered one. Templates without the heading get it appended when there is
+// something to keep.
+func keepRemarks(rendered, current []byte) []byte {
+	remarks, ok := afterHeading(current)
+	if !ok {
+		remarks = nil
+	}
+
+	out, ok := upToHeading(rendered)
+	if !ok {
+		if len(bytes.TrimSpace(remarks)) == 0 {
+			return rendered
+		}
+		out = append(bytes.TrimRight(rendered, "\n"), "\n\n"+remarksHeading+"\n"...)
+	}
+	return append(out, remarks...)
+}
+
+// upToHeading returns note up to and including the 备注 heading line.
+func upToHeading(note []byte) ([]byte, bool) {
+	i := headingLine(note)
+	if i < 0 {
+		return nil, false
+	}
+	out := append([]byte{}, note[:i+len(remarksHeading)]...)
+	return append(out, '\n'), true
+}
+
+// afterHeading returns the part of note below the 备注 heading line.
+func afterHeading(note []byte) ([]byte, bool) {
+	i := headingLine(note)
+	if i < 0 {
+		return nil, false
+	}
+	rest := note[i+len(remarksHeading):]
+	if j := bytes.IndexByte(rest, '\n'); j >= 0 {
+		return rest[j+1:], true
+	}
+	return nil, true
+}
+
+// headingLine returns the offset of the line holding only the 备注 heading, or -1.
+func headingLine(note []byte) int {
+	offset := 0
+	for _, line := range bytes.SplitAfter(note, []byte("\n")) {
+		if string(bytes.TrimSpace(line)) == remarksHeading {
+			return offset + bytes.Index(line, []byte(remarksHeading))
+		}
+		offset += len(line)
+	}
+	return -1
+}
+
+// syncDailyNotes writes the worklogs of the last opts.Days days into the chronicle
+// section of each day's daily note, creating the note if needed. Days without
+// worklogs are skipped. Returns the paths written, relative to the vault.
+func syncDailyNotes(d *model.Dump, noteNames map[string]string, opts VaultOptions, loc *time.Location) ([]string, error) {
+	if opts.Days <= 0 {
+		return nil, nil
+	}
+	now := opts.Now.In(loc)
+	first := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, -(opts.Days - 1))
+
+	titles := make(map[string]string)
+	for _, t := range d.Tasks {
+		titles[t.ID] = t.Title
+	}
+
+	logs := append([]model.TaskLog{}, d.Logs...)
+	sort.SliceStable(logs, func(i, j int) bool { return logs[i].CreatedAt.Before(logs[j].CreatedAt) })
+
+	days := make(map[string][]string)
+	for _, l := range logs {
+		at := l.CreatedAt.In(loc)
+		if at.Before(first) {
+			continue
+		}
+		name, ok := noteNames[l.TaskID]
+		if !ok {
+			continue
+		}
+		alias := strings.NewReplacer("|", "-", "[", "-", "]", "-").Replace(titles[l.TaskID])
+		entry := fmt.Sprintf("- %s [[%s|%s]] %s", at.Format("15:04"), name, alias, strings.ReplaceAll(l.LogText, "\n", " "))
+		if l.ProgressNote != "" {
+			entry += fmt.Sprintf(" (*%s*)", strings.ReplaceAll(l.ProgressNote, "\n", " "))
+		}
+		date := at.Format("2006-01-02")
+		days[date] = append(days[date], entry)
+	}
+
+	var dates []string
+	for date := range days {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+
+	dir := filepath.Join(opts.Vault, opts.DailyFolder)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	var written []string
+	for _, date := range dates {
+		block := dailyBlockStart + "\n## Chronicle\n\n" + strings.Join(days[date], "\n") + "\n" + dailyBlockEnd + "\n"
+
+		path := filepath.Join(dir, date+".md")
+		current, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		content := replaceDailyBlock(string(current), block)
+		if current != nil && content == string(current) {
+			continue
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return nil, err
+		}
+		written = append(written, filepath.Join(opts.DailyFolder, date+".md"))
+	}
+	return written, nil
+}
+
+// replaceDailyBlock replaces the chronicle section of a daily note, or appends it.
+func replaceDailyBlock(note, block string) string {
+	start := strings.Index(note, dailyBlockStart)
+	if start >= 0 {
+		if end := strings.Index(note[start:], dailyBlockEnd); end >= 0 {
+			end += start + len(dailyBlockEnd)
+			if end < len(note) && note[end] == '\n' {
+				end++
+			}
+			return note[:start] + block + note[end:]
+		}
+	}
+	if note == "" {
+		return block
+	}
+	return strings.TrimRight(note, "\n") + "\n\n" + block
+}
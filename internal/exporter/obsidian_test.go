@@ -0,0 +1,80 @@
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yuyudeqiu/chronicle/internal/model"
+)
+
+func TestKeepRemarks(t *testing.T) {
+	rendered := []byte("# Task\n\nnew body\n\n" + remarksHeading + "\n")
+
+	for _, tt := range []struct {
+		name     string
+		rendered []byte
+		current  []byte
+		want     string
+	}{
+		{"new note", rendered, nil, string(rendered)},
+		{"remarks kept", rendered, []byte("# Task\n\nold body\n\n" + remarksHeading + "\nmy notes\n- [ ] follow up\n"), "# Task\n\nnew body\n\n" + remarksHeading + "\nmy notes\n- [ ] follow up\n"},
+		{"heading removed by the user", rendered, []byte("# Task\n\nedited\n"), string(rendered)},
+		{"template without heading", []byte("# Task\n"), []byte("# Task\n\n" + remarksHeading + "\nmine\n"), "# Task\n\n" + remarksHeading + "\nmine\n"},
+		{"template without heading, nothing to keep", []byte("# Task\n"), []byte("# Task\n\n" + remarksHeading + "\n"), "# Task\n"},
+	} {
+		if got := string(keepRemarks(tt.rendered, tt.current)); got != tt.want {
+			t.Errorf("%s: keepRemarks = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSyncVaultKeepsRemarks(t *testing.T) {
+	vault := t.TempDir()
+	now := time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC)
+	task := model.Task{ID: "0f0e0d0c-0000-4000-8000-000000000001", Title: "write docs", Category: "dev", Status: model.TaskStatusTodo, CreatedAt: now, UpdatedAt: now}
+	opts := VaultOptions{Vault: vault, Folder: "Chronicle", Now: now}
+	sync := func(task model.Task) *model.VaultSyncResult {
+		t.Helper()
+		result, err := SyncVault(&model.Dump{Tasks: []model.Task{task}}, opts, time.UTC)
+		if err != nil {
+			t.Fatalf("SyncVault: %v", err)
+		}
+		return result
+	}
+
+	sync(task)
+	path := filepath.Join(vault, "Chronicle", "write docs (0f0e0d0c).md")
+	note, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("note not created: %v", err)
+	}
+	remarks := "ask the team about the layout\n"
+	if err := os.WriteFile(path, append(note, remarks...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if result := sync(task); result.Unchanged != 1 {
+		t.Errorf("syncing again = %+v, want the note unchanged", result)
+	}
+
+	task.Title = "write user docs"
+	task.Status = model.TaskStatusInProgress
+	result := sync(task)
+	renamed := filepath.Join(vault, "Chronicle", "write user docs (0f0e0d0c).md")
+	if len(result.Renamed) != 1 || len(result.Updated) != 1 {
+		t.Errorf("after a rename = %+v, want the note renamed and updated", result)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("note under the old title still exists")
+	}
+	note, err = os.ReadFile(renamed)
+	if err != nil {
+		t.Fatalf("renamed note: %v", err)
+	}
+	if !strings.Contains(string(note), "write user docs") || !strings.HasSuffix(string(note), remarksHeading+"\n"+remarks) {
+		t.Errorf("renamed note lost the new title or the remarks:\n%s", note)
+	}
+}
@@ -0,0 +1,131 @@
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/yuyudeqiu/chronicle/internal/model"
+)
+
+// orgKeywords maps task statuses to the TODO keywords declared in the file header.
+var orgKeywords = map[string]string{
+	model.TaskStatusTodo:       "TODO",
+	model.TaskStatusInProgress: "STARTED",
+	model.TaskStatusDone:       "DONE",
+}
+
+// orgFormat writes an Emacs org-mode outline: one heading per task with its
+// deadline, properties, sections and worklogs by day.
+type orgFormat struct{}
+
+func (orgFormat) Name() string        { return "org" }
+func (orgFormat) Description() string { return "Emacs org-mode outline" }
+func (orgFormat) Ext() string         { return ".org" }
+func (orgFormat) ContentType() string { return "text/org; charset=utf-8" }
+func (orgFormat) Zip() bool           { return false }
+
+func (orgFormat) Render(w io.Writer, d *model.Dump, loc *time.Location) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "#+TITLE: Chronicle\n#+DATE: %s\n#+TODO: TODO STARTED | DONE\n", orgTime(d.ExportedAt, loc, false))
+
+	logsByTask := make(map[string][]model.TaskLog)
+	for _, l := range d.Logs {
+		logsByTask[l.TaskID] = append(logsByTask[l.TaskID], l)
+	}
+
+	for _, t := range d.Tasks {
+		fmt.Fprintf(b, "\n* %s %s", orgKeywords[t.Status], t.Title)
+		if tag := orgTag(t.Category); tag != "" {
+			fmt.Fprintf(b, " :%s:", tag)
+		}
+		b.WriteString("\n")
+
+		var planning []string
+		if t.ActualCompletedAt != nil {
+			planning = append(planning, "CLOSED: "+orgTime(*t.ActualCompletedAt, loc, false))
+		}
+		if t.Deadline != nil {
+			planning = append(planning, "DEADLINE: "+orgTime(*t.Deadline, loc, true))
+		}
+		if len(planning) > 0 {
+			fmt.Fprintf(b, "%s\n", strings.Join(planning, " "))
+		}
+
+		b.WriteString(":PROPERTIES:\n")
+		fmt.Fprintf(b, ":ID:       %s\n", t.ID)
+		fmt.Fprintf(b, ":CATEGORY: %s\n", t.Category)
+		fmt.Fprintf(b, ":CREATED:  %s\n", orgTime(t.CreatedAt, loc, false))
+		if t.ArchivedAt != nil {
+			fmt.Fprintf(b, ":ARCHIVED: %s\n", orgTime(*t.ArchivedAt, loc, false))
+		}
+		b.WriteString(":END:\n")
+
+		for _, section := range []struct{ title, text string }{
+			{"任务描述", t.Description},
+			{"目标", t.Targets},
+			{"相关链接", t.Links.String()},
+		} {
+			if strings.TrimSpace(section.text) == "" {
+				continue
+			}
+			fmt.Fprintf(b, "** %s\n%s\n", section.title, orgText(section.text))
+		}
+
+		logs := logsByTask[t.ID]
+		if len(logs) == 0 {
+			continue
+		}
+		sort.SliceStable(logs, func(i, j int) bool { return logs[i].CreatedAt.Before(logs[j].CreatedAt) })
+		b.WriteString("** 工作记录\n")
+		day := ""
+		for _, l := range logs {
+			at := l.CreatedAt.In(loc)
+			if d := at.Format("2006-01-02"); d != day {
+				day = d
+				fmt.Fprintf(b, "*** [%s]\n", at.Format("2006-01-02 Mon"))
+			}
+			line := fmt.Sprintf("- %s %s", at.Format("15:04"), strings.ReplaceAll(l.LogText, "\n", " "))
+			if l.ProgressNote != "" {
+				line += " /" + l.ProgressNote + "/"
+			}
+			fmt.Fprintf(b, "%s\n", line)
+		}
+	}
+	return b.Flush()
+}
+
+// orgTime formats an org timestamp, active (<...>) for deadlines and inactive
+// ([...]) for everything else.
+func orgTime(t time.Time, loc *time.Location, active bool) string {
+	s := t.In(loc).Format("2006-01-02 Mon 15:04")
+	if active {
+		return "<" + s + ">"
+	}
+	return "[" + s + "]"
+}
+
+// orgTag turns a category into a tag, which may only hold letters, digits, _ and @.
+func orgTag(category string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '@' {
+			return r
+		}
+		return '_'
+	}, category)
+}
+
+// orgText escapes lines that org would read as headings or keywords.
+func orgText(s string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "*") || strings.HasPrefix(line, "#+") {
+			lines[i] = "," + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
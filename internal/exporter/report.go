@@ -0,0 +1,22 @@
+package exporter
+
+import (
+	"bytes"
+
+	"github.com/yuyudeqiu/chronicle/internal/model"
+)
+
+// RenderPeriodMarkdown renders a period summary (week, month or custom range) as Markdown.
+func RenderPeriodMarkdown(summary *model.PeriodSummaryResp) ([]byte, error) {
+	tmpl, err := loadTemplate(reportTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, summary); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
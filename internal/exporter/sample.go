@@ -0,0 +1,126 @@
+package exporter
+
+import (
+	"time"
+
+	"github.com/yuyudeqiu/chronicle/internal/model"
+)
+
+// sampleDump is the data templates are validated against: one task in progress
+// with a checklist and one finished task, with worklogs relative to now.
+func sampleDump(now time.Time) *model.Dump {
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d).Truncate(time.Minute)
+		return &t
+	}
+	day := 24 * time.Hour
+
+	return &model.Dump{
+		Format:     model.DumpFormat,
+		Version:    model.DumpVersion,
+		ExportedAt: now,
+		Tasks: []model.Task{
+			{
+				ID:          "3f2b9c1e-7a4d-4e8b-9c2f-1a5d6e7f8a9b",
+				Title:       "编写季度报告",
+				Category:    "工作",
+				Description: "汇总本季度的 *关键* 指标与项目进展。",
+				Targets:     "- [x] 收集数据\n- [x] 撰写初稿\n- [ ] 评审",
+				Links: model.TaskLinks{
+					{URL: "https://example.com/wiki/q3-report", Kind: model.LinkKindDoc, Label: "报告草稿"},
+					{URL: "https://github.com/example/metrics/issues/42", Kind: model.LinkKindIssue},
+				},
+				Status:    model.TaskStatusInProgress,
+				Deadline:  at(2 * day),
+				CreatedAt: *at(-5 * day),
+				UpdatedAt: *at(-2 * time.Hour),
+			},
+			{
+				ID:                "8c7d6e5f-4a3b-4c2d-9e1f-0a9b8c7d6e5f",
+				Title:             "升级依赖",
+				Category:          "维护",
+				Targets:           "所有服务升级到最新的安全补丁版本",
+				Status:            model.TaskStatusDone,
+				ActualCompletedAt: at(-1 * day),
+				CreatedAt:         *at(-3 * day),
+				UpdatedAt:         *at(-1 * day),
+			},
+		},
+		Logs: []model.TaskLog{
+			{ID: "sample-log-1", TaskID: "3f2b9c1e-7a4d-4e8b-9c2f-1a5d6e7f8a9b", LogText: "整理了各项目的数据", CreatedAt: *at(-3 * day)},
+			{ID: "sample-log-2", TaskID: "3f2b9c1e-7a4d-4e8b-9c2f-1a5d6e7f8a9b", LogText: "完成初稿", ProgressNote: "60%", CreatedAt: *at(-2 * time.Hour)},
+			{ID: "sample-log-3", TaskID: "8c7d6e5f-4a3b-4c2d-9e1f-0a9b8c7d6e5f", LogText: "升级并通过了回归测试", CreatedAt: *at(-1 * day)},
+		},
+		StatusChanges: []model.TaskStatusChange{},
+	}
+}
+
+// sampleReport is the period summary report templates are validated against.
+func sampleReport(now time.Time) *model.PeriodSummaryResp {
+	from := now.AddDate(0, 0, -6)
+	return &model.PeriodSummaryResp{
+		From:      from.Format("2006-01-02"),
+		To:        now.Format("2006-01-02"),
+		TotalLogs: 3,
+		Categories: []model.PeriodCategoryActivity{
+			{Category: "工作", Tasks: []model.PeriodTaskActivity{
+				{TaskID: "3f2b9c1e-7a4d-4e8b-9c2f-1a5d6e7f8a9b", TaskTitle: "编写季度报告", Status: model.TaskStatusInProgress, Logs: []string{"整理了各项目的数据", "完成初稿"}},
+			}},
+			{Category: "维护", Tasks: []model.PeriodTaskActivity{
+				{TaskID: "8c7d6e5f-4a3b-4c2d-9e1f-0a9b8c7d6e5f", TaskTitle: "升级依赖", Status: model.TaskStatusDone, Logs: []string{"升级并通过了回归测试"}},
+			}},
+		},
+		Completed: []model.PeriodTaskRef{{TaskID: "8c7d6e5f-4a3b-4c2d-9e1f-0a9b8c7d6e5f", TaskTitle: "升级依赖", Category: "维护", At: now.AddDate(0, 0, -1).Format("2006-01-02 15:04")}},
+		Started:   []model.PeriodTaskRef{{TaskID: "3f2b9c1e-7a4d-4e8b-9c2f-1a5d6e7f8a9b", TaskTitle: "编写季度报告", Category: "工作", At: from.Format("2006-01-02 15:04")}},
+		Slipped:   []model.PeriodTaskRef{},
+	}
+}
+
+// sampleSite is the static site data templates are validated against.
+func sampleSite(now time.Time) SiteData {
+	heatmap := &model.HeatmapResp{
+		From:          now.AddDate(0, 0, -13).Format("2006-01-02"),
+		To:            now.Format("2006-01-02"),
+		TotalLogs:     3,
+		ActiveDays:    3,
+		CurrentStreak: 2,
+		LongestStreak: 2,
+	}
+	for i := 13; i >= 0; i-- {
+		count := 0
+		if i == 0 || i == 1 || i == 3 {
+			count = 1
+		}
+		heatmap.Days = append(heatmap.Days, model.HeatmapDay{Date: now.AddDate(0, 0, -i).Format("2006-01-02"), Count: count})
+	}
+
+	stats := &model.StatsSummaryResp{
+		TotalTasks:      2,
+		CompletedTasks:  1,
+		InProgressTasks: 1,
+		ByCategory:      map[string]int{"工作": 1, "维护": 1},
+		CompletionRate:  0.5,
+	}
+	for i := 6; i >= 0; i-- {
+		day := model.DailyStats{Date: now.AddDate(0, 0, -i).Format("2006-01-02")}
+		if i == 1 {
+			day.Completed = 1
+		}
+		stats.WeeklyStats = append(stats.WeeklyStats, day)
+	}
+
+	week := now.AddDate(0, 0, -7).Format("2006-01-02")
+	return SiteData{
+		Dump:  sampleDump(now),
+		Stats: stats,
+		Flow: &model.FlowStatsResp{
+			Since:      week,
+			LeadTime:   model.DurationStats{Count: 1, AvgHours: 48, P50Hours: 48, P85Hours: 48, P95Hours: 48},
+			CycleTime:  model.DurationStats{Count: 1, AvgHours: 24, P50Hours: 24, P85Hours: 24, P95Hours: 24},
+			ByCategory: map[string]model.CategoryFlowStats{},
+			Throughput: []model.WeeklyThroughput{{WeekStart: week, Completed: 0}, {WeekStart: now.Format("2006-01-02"), Completed: 1}},
+		},
+		Heatmap: heatmap,
+		Now:     now,
+	}
+}
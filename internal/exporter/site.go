@@ -0,0 +1,370 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yuyudeqiu/chronicle/internal/model"
+)
+
+// siteTemplate renders every page of the static site (chronicle site build).
+const siteTemplate = "site.tmpl"
+
+// siteManifest lists the pages of the last build in the site directory. Only pages
+// listed there are ever removed, and its presence marks the directory as a site.
+const siteManifest = ".chronicle-site.json"
+
+// ErrSiteDirNotEmpty is returned when building into a non-empty directory that
+// was not built by chronicle, so none of its files are overwritten or removed.
+var ErrSiteDirNotEmpty = errors.New("site directory is not empty")
+
+// manifest is the content of siteManifest.
+type manifest struct {
+	Generator string   `json:"generator"`
+	Pages     []string `json:"pages"`
+}
+
+// SiteData is what a static site is built from: the same tasks and statistics the
+// API serves.
+type SiteData struct {
+	Dump    *model.Dump
+	Stats   *model.StatsSummaryResp
+	Flow    *model.FlowStatsResp
+	Heatmap *model.HeatmapResp
+	// Now is the build time; "this week" is the week (from Monday) containing it.
+	Now time.Time
+}
+
+// SitePage is the data of each page of the site template. Page tells which page
+// is rendered and which of the other fields are set.
+type SitePage struct {
+	// Page is "index", "task", "completed" or "stats".
+	Page  string
+	Title string
+	// Root is the relative path from the page to the site root: "" or "../".
+	Root      string
+	Generated time.Time
+
+	// index: open tasks grouped by category
+	Categories []SiteCategory
+	OpenTasks  int
+
+	// task
+	Task *TaskView
+
+	// completed: tasks completed this week, by day (newest first)
+	WeekStart time.Time
+	Days      []SiteDay
+	Completed int
+
+	// stats
+	Stats      *model.StatsSummaryResp
+	Flow       *model.FlowStatsResp
+	Heatmap    *model.HeatmapResp
+	Activity   []SiteActivity
+	Throughput []SiteBar
+	ByCategory []SiteBar
+	// HeatCells lays the heatmap out in columns of weeks starting on Monday; padding
+	// cells before the first day have an empty Date.
+	HeatCells []SiteHeatCell
+}
+
+type SiteCategory struct {
+	Name  string
+	Tasks []TaskView
+}
+
+type SiteDay struct {
+	Date  time.Time
+	Tasks []TaskView
+}
+
+// SiteBar is a bar of a chart; Percent is its height relative to the largest bar.
+type SiteBar struct {
+	Label   string
+	Value   int
+	Percent int
+}
+
+// SiteActivity is one day of the created/completed chart.
+type SiteActivity struct {
+	Date             string
+	Created          int
+	Completed        int
+	CreatedPercent   int
+	CompletedPercent int
+}
+
+// SiteHeatCell is a day of the heatmap with its intensity Level from 0 to 4.
+type SiteHeatCell struct {
+	Date  string
+	Count int
+	Level int
+}
+
+// BuildSite renders a self-contained static HTML site into dir: index.html with
+// the open tasks by category, tasks/<id>.html with each task's worklog timeline,
+// completed.html with this week's completed tasks and stats.html with charts.
+// Archived tasks are left out, and pages the previous build wrote for tasks that
+// are gone are removed. dir must be empty, missing or a previous build's output.
+func BuildSite(dir string, data SiteData, loc *time.Location) (*model.SiteBuildResult, error) {
+	tmpl, err := loadHTMLTemplate(siteTemplate)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := readManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "tasks"), 0755); err != nil {
+		return nil, err
+	}
+
+	result := &model.SiteBuildResult{Dir: dir, Pages: []string{}, Removed: []string{}}
+	written := make(map[string]bool)
+	err = renderSite(tmpl, data, loc, func(name string, content []byte) error {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			return err
+		}
+		written[name] = true
+		result.Pages = append(result.Pages, name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range previous {
+		if written[name] {
+			continue
+		}
+		err := os.Remove(filepath.Join(dir, filepath.FromSlash(name)))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Removed = append(result.Removed, name)
+	}
+
+	m, err := json.MarshalIndent(manifest{Generator: "chronicle", Pages: result.Pages}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, siteManifest), m, 0644); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// readManifest returns the pages written by the previous build into dir. A missing
+// or empty dir has none; any other dir without a manifest is refused.
+func readManifest(dir string) ([]string, error) {
+	content, err := os.ReadFile(filepath.Join(dir, siteManifest))
+	if errors.Is(err, os.ErrNotExist) {
+		entries, err := os.ReadDir(dir)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		if len(entries) > 0 {
+			return nil, fmt.Errorf("%w: %s has no %s from a previous build; choose an empty or new directory", ErrSiteDirNotEmpty, dir, siteManifest)
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var m manifest
+	if err := json.Unmarshal(content, &m); err != nil {
+		return nil, fmt.Errorf("read %s: %w", filepath.Join(dir, siteManifest), err)
+	}
+	// Never follow a tampered manifest outside the site's own pages
+	var pages []string
+	for _, name := range m.Pages {
+		if name == path.Clean(name) && !path.IsAbs(name) && !strings.HasPrefix(name, "../") && strings.HasSuffix(name, ".html") {
+			pages = append(pages, name)
+		}
+	}
+	return pages, nil
+}
+
+// renderSite executes tmpl for every page of the site and passes the pages to
+// write by their path relative to the site root.
+func renderSite(tmpl templater, data SiteData, loc *time.Location, write func(name string, content []byte) error) error {
+	now := data.Now.In(loc)
+	d := *data.Dump
+	d.Tasks = nil
+	for _, t := range data.Dump.Tasks {
+		if t.ArchivedAt == nil {
+			d.Tasks = append(d.Tasks, t)
+		}
+	}
+	views := taskViews(&d, loc)
+
+	page := func(p SitePage) error {
+		p.Generated = now
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, p); err != nil {
+			return fmt.Errorf("render %s page: %w", p.Page, err)
+		}
+		name := "index.html"
+		switch p.Page {
+		case "task":
+			name = "tasks/" + p.Task.ID + ".html"
+		case "completed", "stats":
+			name = p.Page + ".html"
+		}
+		return write(name, buf.Bytes())
+	}
+
+	index := SitePage{Page: "index", Title: "进行中的任务"}
+	byCategory := make(map[string][]TaskView)
+	for _, v := range views {
+		if v.Status != model.TaskStatusDone {
+			byCategory[v.Category] = append(byCategory[v.Category], v)
+			index.OpenTasks++
+		}
+	}
+	for _, name := range sortedKeys(byCategory) {
+		tasks := byCategory[name]
+		sort.SliceStable(tasks, func(i, j int) bool { return deadlineBefore(tasks[i].Task.Deadline, tasks[j].Task.Deadline) })
+		index.Categories = append(index.Categories, SiteCategory{Name: name, Tasks: tasks})
+	}
+	if err := page(index); err != nil {
+		return err
+	}
+
+	for i := range views {
+		if err := page(SitePage{Page: "task", Title: views[i].Title, Root: "../", Task: &views[i]}); err != nil {
+			return err
+		}
+	}
+
+	weekStart := time.Date(now.Year(), now.Month(), now.Day()-(int(now.Weekday())+6)%7, 0, 0, 0, 0, loc)
+	completed := SitePage{Page: "completed", Title: "本周完成", WeekStart: weekStart}
+	byDay := make(map[string]*SiteDay)
+	for _, v := range views {
+		at := v.Task.ActualCompletedAt
+		if v.Status != model.TaskStatusDone || at == nil || at.Before(weekStart) {
+			continue
+		}
+		key := at.Format("2006-01-02")
+		if byDay[key] == nil {
+			byDay[key] = &SiteDay{Date: time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, loc)}
+		}
+		byDay[key].Tasks = append(byDay[key].Tasks, v)
+		completed.Completed++
+	}
+	keys := sortedKeys(byDay)
+	for i := len(keys) - 1; i >= 0; i-- {
+		day := byDay[keys[i]]
+		sort.SliceStable(day.Tasks, func(a, b int) bool {
+			return day.Tasks[a].Task.ActualCompletedAt.After(*day.Tasks[b].Task.ActualCompletedAt)
+		})
+		completed.Days = append(completed.Days, *day)
+	}
+	if err := page(completed); err != nil {
+		return err
+	}
+
+	return page(statsPage(data))
+}
+
+// statsPage prepares the charts of the stats page.
+func statsPage(data SiteData) SitePage {
+	p := SitePage{Page: "stats", Title: "统计", Stats: data.Stats, Flow: data.Flow, Heatmap: data.Heatmap}
+
+	if data.Stats != nil {
+		most := 0
+		for _, s := range data.Stats.WeeklyStats {
+			most = max(most, s.Created, s.Completed)
+		}
+		for _, s := range data.Stats.WeeklyStats {
+			p.Activity = append(p.Activity, SiteActivity{
+				Date:             s.Date,
+				Created:          s.Created,
+				Completed:        s.Completed,
+				CreatedPercent:   percentOf(s.Created, most),
+				CompletedPercent: percentOf(s.Completed, most),
+			})
+		}
+
+		var bars []SiteBar
+		for _, name := range sortedKeys(data.Stats.ByCategory) {
+			bars = append(bars, SiteBar{Label: name, Value: data.Stats.ByCategory[name]})
+		}
+		sort.SliceStable(bars, func(i, j int) bool { return bars[i].Value > bars[j].Value })
+		p.ByCategory = scaleBars(bars)
+	}
+
+	if data.Flow != nil {
+		var bars []SiteBar
+		for _, w := range data.Flow.Throughput {
+			bars = append(bars, SiteBar{Label: w.WeekStart, Value: w.Completed})
+		}
+		p.Throughput = scaleBars(bars)
+	}
+
+	if data.Heatmap != nil && len(data.Heatmap.Days) > 0 {
+		most := 0
+		for _, d := range data.Heatmap.Days {
+			most = max(most, d.Count)
+		}
+		if first, err := time.Parse("2006-01-02", data.Heatmap.Days[0].Date); err == nil {
+			p.HeatCells = make([]SiteHeatCell, (int(first.Weekday())+6)%7)
+		}
+		for _, d := range data.Heatmap.Days {
+			level := 0
+			if d.Count > 0 {
+				level = 1 + (d.Count*4-1)/most
+			}
+			p.HeatCells = append(p.HeatCells, SiteHeatCell{Date: d.Date, Count: d.Count, Level: level})
+		}
+	}
+	return p
+}
+
+func scaleBars(bars []SiteBar) []SiteBar {
+	most := 0
+	for _, b := range bars {
+		most = max(most, b.Value)
+	}
+	for i := range bars {
+		bars[i].Percent = percentOf(bars[i].Value, most)
+	}
+	return bars
+}
+
+func percentOf(v, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return v * 100 / total
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// deadlineBefore orders tasks by deadline, with tasks without one last.
+func deadlineBefore(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a != nil
+	}
+	return a.Before(*b)
+}
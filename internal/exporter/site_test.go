@@ -0,0 +1,69 @@
+package exporter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yuyudeqiu/chronicle/internal/model"
+)
+
+func siteData(ids ...string) SiteData {
+	now := time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC)
+	d := &model.Dump{}
+	for _, id := range ids {
+		d.Tasks = append(d.Tasks, model.Task{ID: id, Title: "task " + id, Category: "dev", Status: model.TaskStatusTodo, CreatedAt: now, UpdatedAt: now})
+	}
+	return SiteData{Dump: d, Now: now}
+}
+
+func TestBuildSiteRemovesOnlyItsOwnPages(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "site")
+
+	if _, err := BuildSite(dir, siteData("a", "b"), time.UTC); err != nil {
+		t.Fatalf("first build: %v", err)
+	}
+	// A file the user put next to the generated pages must survive rebuilds
+	other := filepath.Join(dir, "tasks", "notes.html")
+	if err := os.WriteFile(other, []byte("mine"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := BuildSite(dir, siteData("a"), time.UTC)
+	if err != nil {
+		t.Fatalf("second build: %v", err)
+	}
+	if want := []string{"tasks/b.html"}; !reflect.DeepEqual(result.Removed, want) {
+		t.Errorf("removed = %v, want %v", result.Removed, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "tasks", "b.html")); !errors.Is(err, os.ErrNotExist) {
+		t.Error("page of the removed task still exists")
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("unrelated file was removed: %v", err)
+	}
+}
+
+func TestBuildSiteRefusesForeignDirectory(t *testing.T) {
+	dir := t.TempDir()
+	page := filepath.Join(dir, "tasks", "keep.html")
+	if err := os.MkdirAll(filepath.Dir(page), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(page, []byte("mine"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := BuildSite(dir, siteData("a"), time.UTC); !errors.Is(err, ErrSiteDirNotEmpty) {
+		t.Fatalf("BuildSite into a foreign directory = %v, want ErrSiteDirNotEmpty", err)
+	}
+	if _, err := os.Stat(page); err != nil {
+		t.Errorf("existing file was touched: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "index.html")); !errors.Is(err, os.ErrNotExist) {
+		t.Error("pages were written into a foreign directory")
+	}
+}
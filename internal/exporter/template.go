@@ -0,0 +1,311 @@
+package exporter
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/yuyudeqiu/chronicle/internal/config"
+	"github.com/yuyudeqiu/chronicle/templates"
+)
+
+// reportTemplate renders period summaries (chronicle report --markdown).
+const reportTemplate = "period_report.tmpl"
+
+// templateFuncs are available in every template, built-in or user-supplied.
+var templateFuncs = map[string]any{
+	// date formats a time.Time or *time.Time with a Go layout; nil gives "".
+	// {{date "2006-01-02" .Task.Deadline}}
+	"date": func(layout string, v any) string {
+		t, ok := timeValue(v)
+		if !ok {
+			return ""
+		}
+		return t.Format(layout)
+	},
+	// duration formats a time.Duration, or the time between two times (a nil end
+	// means now), e.g. "2 天 3 小时". {{duration .Task.CreatedAt .Task.ActualCompletedAt}}
+	"duration": func(args ...any) (string, error) {
+		switch len(args) {
+		case 1:
+			d, ok := args[0].(time.Duration)
+			if !ok {
+				return "", fmt.Errorf("duration: expected a time.Duration, got %T", args[0])
+			}
+			return formatDuration(d), nil
+		case 2:
+			from, ok := timeValue(args[0])
+			if !ok {
+				return "", nil
+			}
+			to, ok := timeValue(args[1])
+			if !ok {
+				to = time.Now()
+			}
+			return formatDuration(to.Sub(from)), nil
+		}
+		return "", fmt.Errorf("duration: expected 1 or 2 arguments, got %d", len(args))
+	},
+	// relative describes a day relative to today: 今天, 昨天, 3 天后...
+	"relative": func(v any) string {
+		t, ok := timeValue(v)
+		if !ok {
+			return ""
+		}
+		now := time.Now().In(t.Location())
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, t.Location())
+		switch days := int(math.Round(day.Sub(today).Hours() / 24)); {
+		case days == 0:
+			return "今天"
+		case days == -1:
+			return "昨天"
+		case days == 1:
+			return "明天"
+		case days < 0:
+			return fmt.Sprintf("%d 天前", -days)
+		default:
+			return fmt.Sprintf("%d 天后", days)
+		}
+	},
+	// md escapes Markdown syntax so text is shown literally.
+	"md": func(s string) string {
+		return markdownEscaper.Replace(s)
+	},
+	// default returns v, or def if v is empty (zero, nil or ""). {{.Deadline | default "无"}}
+	"default": func(def, v any) any {
+		if isEmptyValue(v) {
+			return def
+		}
+		return v
+	},
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`, "~", `\~`,
+)
+
+func timeValue(v any) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, !t.IsZero()
+	case *time.Time:
+		if t == nil || t.IsZero() {
+			return time.Time{}, false
+		}
+		return *t, true
+	}
+	return time.Time{}, false
+}
+
+func isEmptyValue(v any) bool {
+	switch x := v.(type) {
+	case nil:
+		return true
+	case string:
+		return x == ""
+	case int:
+		return x == 0
+	case *time.Time:
+		return x == nil || x.IsZero()
+	case time.Time:
+		return x.IsZero()
+	}
+	return false
+}
+
+func formatDuration(d time.Duration) string {
+	if d < 0 {
+		d = -d
+	}
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+	switch {
+	case days > 0 && hours > 0:
+		return fmt.Sprintf("%d 天 %d 小时", days, hours)
+	case days > 0:
+		return fmt.Sprintf("%d 天", days)
+	case hours > 0 && minutes > 0:
+		return fmt.Sprintf("%d 小时 %d 分钟", hours, minutes)
+	case hours > 0:
+		return fmt.Sprintf("%d 小时", hours)
+	}
+	return fmt.Sprintf("%d 分钟", minutes)
+}
+
+// TemplateDirs lists the directories searched for user templates, in order: the
+// configured templates directory, then templates/ in the data directory.
+func TemplateDirs() []string {
+	var dirs []string
+	if dir := config.GetTemplatesDir(); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	return append(dirs, config.GetUserTemplatesDir())
+}
+
+// findTemplate returns the path of the user copy of the named template, or "" if
+// there is none.
+func findTemplate(name string) string {
+	for _, dir := range TemplateDirs() {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// loadTemplate parses the named template, preferring a user copy over the one
+// embedded in the binary.
+func loadTemplate(name string) (*template.Template, error) {
+	return parseTemplate(name, findTemplate(name))
+}
+
+// loadHTMLTemplate is loadTemplate for HTML templates, which escape their data.
+func loadHTMLTemplate(name string) (*htmltemplate.Template, error) {
+	return parseHTMLTemplate(name, findTemplate(name))
+}
+
+// parseTemplate parses the file at path, or the built-in template if path is empty.
+func parseTemplate(name, path string) (*template.Template, error) {
+	src, err := templateSource(name, path)
+	if err != nil {
+		return nil, err
+	}
+	return template.New(name).Funcs(templateFuncs).Parse(string(src))
+}
+
+func parseHTMLTemplate(name, path string) (*htmltemplate.Template, error) {
+	src, err := templateSource(name, path)
+	if err != nil {
+		return nil, err
+	}
+	return htmltemplate.New(name).Funcs(templateFuncs).Parse(string(src))
+}
+
+func templateSource(name, path string) ([]byte, error) {
+	if path != "" {
+		return os.ReadFile(path)
+	}
+	return fs.ReadFile(templates.FS, name)
+}
+
+// templateName is the file name of the template a format can be customised with.
+// Formats rendered in Go use "<format>.tmpl", which replaces the built-in renderer.
+func templateName(f Format) string {
+	if tf, ok := f.(*templateFormat); ok {
+		return tf.template
+	}
+	return f.Name() + ".tmpl"
+}
+
+// TemplateInfo describes a template that users can supply.
+type TemplateInfo struct {
+	// Name is the file name, e.g. obsidian_task.tmpl.
+	Name string `json:"name"`
+	// Format is the export format rendered with it, "report" for period reports or
+	// "site" for the static site.
+	Format string `json:"format"`
+	// Path is the user copy in effect, empty if the built-in one is used.
+	Path string `json:"path"`
+	// Builtin is false for formats rendered in Go, which have no built-in template.
+	Builtin bool `json:"builtin"`
+}
+
+// Templates lists the templates of all formats, of period reports and of the
+// static site.
+func Templates() []TemplateInfo {
+	var list []TemplateInfo
+	add := func(name, format string) {
+		_, err := fs.Stat(templates.FS, name)
+		list = append(list, TemplateInfo{Name: name, Format: format, Path: findTemplate(name), Builtin: err == nil})
+	}
+	for _, f := range Formats() {
+		add(templateName(f), f.Name())
+	}
+	add(reportTemplate, "report")
+	add(siteTemplate, "site")
+	return list
+}
+
+// LookupTemplate finds a template by file name or by the format it renders.
+func LookupTemplate(name string) (TemplateInfo, bool) {
+	for _, t := range Templates() {
+		if t.Name == name || t.Format == name {
+			return t, true
+		}
+	}
+	return TemplateInfo{}, false
+}
+
+// TemplateSource returns the source of a template in effect: the user copy, or the
+// built-in one.
+func TemplateSource(t TemplateInfo) ([]byte, error) {
+	if t.Path == "" && !t.Builtin {
+		return nil, fmt.Errorf("%s is rendered by chronicle itself and has no built-in template; create %s to replace it", t.Format, t.Name)
+	}
+	return templateSource(t.Name, t.Path)
+}
+
+// ValidateTemplate parses the file at path (the template in effect if empty) as
+// template t and renders it against sample data in loc, returning the output.
+func ValidateTemplate(t TemplateInfo, path string, loc *time.Location) ([]byte, error) {
+	if path == "" {
+		path = t.Path
+	}
+	var tmpl templater
+	var err error
+	if f, ok := Lookup(t.Format); (ok && isHTML(f)) || t.Format == "site" {
+		tmpl, err = parseHTMLTemplate(t.Name, path)
+	} else {
+		tmpl, err = parseTemplate(t.Name, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var buf strings.Builder
+	switch {
+	case t.Format == "report":
+		err = tmpl.Execute(&buf, sampleReport(time.Now()))
+	case t.Format == "site":
+		err = renderSite(tmpl, sampleSite(time.Now()), loc, func(name string, content []byte) error {
+			buf.Write(content)
+			return nil
+		})
+	case isPerTask(t.Format):
+		for _, v := range taskViews(sampleDump(time.Now()), loc) {
+			if err = tmpl.Execute(&buf, v); err != nil {
+				break
+			}
+		}
+	default:
+		err = tmpl.Execute(&buf, newDocumentView(sampleDump(time.Now()), loc))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte(buf.String()), nil
+}
+
+func isHTML(f Format) bool {
+	tf, ok := f.(*templateFormat)
+	return ok && tf.html
+}
+
+func isPerTask(format string) bool {
+	f, ok := Lookup(format)
+	if !ok {
+		return false
+	}
+	tf, ok := f.(*templateFormat)
+	return ok && tf.perTask
+}
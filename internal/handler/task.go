@@ -1,10 +1,17 @@
 package handler
 
 import (
+	"crypto/subtle"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/yuyudeqiu/chronicle/internal/config"
 	"github.com/yuyudeqiu/chronicle/internal/exporter"
+	"github.com/yuyudeqiu/chronicle/internal/importer"
 	"github.com/yuyudeqiu/chronicle/internal/model"
 	"github.com/yuyudeqiu/chronicle/internal/service"
 )
@@ -16,7 +23,8 @@ var (
 	BuildTime  string
 )
 
-func RegisterRoutes(r *gin.Engine) {
+// RegisterRoutes mounts the API under r, which may be a base-path group.
+func RegisterRoutes(r gin.IRouter) {
 	v1 := r.Group("/api/v1")
 	{
 		v1.GET("/version", GetVersion)
@@ -31,11 +39,68 @@ func RegisterRoutes(r *gin.Engine) {
 		v1.POST("/tasks/:id/unarchive", UnarchiveTask)
 		v1.DELETE("/worklogs/:id", DeleteWorklog)
 		v1.GET("/reports/daily-summary", GetDailySummary)
+		v1.GET("/reports/summary", GetPeriodSummary)
 		v1.GET("/exports/daily-markdown", GetDailyMarkdown)
+		v1.GET("/exports/full", GetFullExport)
+		v1.GET("/exports/csv", GetCSVExport)
+		v1.GET("/exports", ListExportFormats)
+		v1.GET("/exports/:format", GetExport)
+		v1.POST("/imports", ImportDump)
+		v1.POST("/imports/csv", ImportCSV)
+		v1.POST("/imports/git", ImportCommits)
+		v1.POST("/imports/:format", ImportTaskList)
+		v1.GET("/calendar.ics", GetCalendar)
 		v1.GET("/stats/summary", GetStatsSummary)
+		v1.GET("/stats/flow", GetFlowStats)
+		v1.GET("/stats/cfd", GetCumulativeFlow)
+		v1.GET("/stats/burndown", GetBurndown)
+		v1.GET("/stats/heatmap", GetWorklogHeatmap)
+		v1.GET("/agent/context", GetAgentContext)
 	}
 }
 
+// requestLocation resolves the timezone used for day boundaries: the `tz` query
+// parameter if given, otherwise the configured timezone. On failure it writes a 400
+// response and returns false.
+func requestLocation(c *gin.Context) (*time.Location, bool) {
+	name := c.Query("tz")
+	if name == "" {
+		name = config.GetTimezone()
+	}
+	loc, err := config.LoadLocation(name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResp(400, err.Error()))
+		return nil, false
+	}
+	return loc, true
+}
+
+// resolveTaskID resolves the :id parameter, which may be a full task id, a short id
+// or a unique prefix. If it matches no task or several, it writes a 404 or 400
+// response and returns false.
+func resolveTaskID(c *gin.Context) (string, bool) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, model.ErrorResp(400, "missing task id"))
+		return "", false
+	}
+
+	resolved, err := service.ResolveTaskID(id)
+	if err != nil {
+		if errors.Is(err, service.ErrTaskNotFound) {
+			c.JSON(http.StatusNotFound, model.ErrorResp(404, err.Error()))
+			return "", false
+		}
+		if errors.Is(err, service.ErrAmbiguousTaskID) {
+			c.JSON(http.StatusBadRequest, model.ErrorResp(400, err.Error()))
+			return "", false
+		}
+		c.JSON(http.StatusInternalServerError, model.ErrorResp(500, "failed to resolve task id: "+err.Error()))
+		return "", false
+	}
+	return resolved, true
+}
+
 func GetVersion(c *gin.Context) {
 	c.JSON(http.StatusOK, model.SuccessResp(map[string]string{
 		"git_commit":  GitCommit,
@@ -53,6 +118,10 @@ func CreateTask(c *gin.Context) {
 
 	task, err := service.CreateTask(req)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidLink) || errors.Is(err, service.ErrInvalidParent) {
+			c.JSON(http.StatusBadRequest, model.ErrorResp(400, err.Error()))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, model.ErrorResp(500, "failed to create task: "+err.Error()))
 		return
 	}
@@ -61,6 +130,16 @@ func CreateTask(c *gin.Context) {
 }
 
 func GetActiveTasks(c *gin.Context) {
+	if ref := c.Query("ref"); ref != "" {
+		tasks, err := service.FindTasksByRef(ref)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, model.ErrorResp(500, "failed to get tasks: "+err.Error()))
+			return
+		}
+		c.JSON(http.StatusOK, model.SuccessResp(tasks))
+		return
+	}
+
 	status := c.Query("status")
 	if status == "" || status == "in-progress,todo" || status == "todo,in-progress" {
 		tasks, err := service.GetActiveTasks()
@@ -88,9 +167,8 @@ func GetActiveTasks(c *gin.Context) {
 }
 
 func DeleteTask(c *gin.Context) {
-	id := c.Param("id")
-	if id == "" {
-		c.JSON(http.StatusBadRequest, model.ErrorResp(400, "missing task id"))
+	id, ok := resolveTaskID(c)
+	if !ok {
 		return
 	}
 
@@ -118,9 +196,8 @@ func DeleteWorklog(c *gin.Context) {
 }
 
 func GetTask(c *gin.Context) {
-	id := c.Param("id")
-	if id == "" {
-		c.JSON(http.StatusBadRequest, model.ErrorResp(400, "missing task id"))
+	id, ok := resolveTaskID(c)
+	if !ok {
 		return
 	}
 
@@ -134,9 +211,8 @@ func GetTask(c *gin.Context) {
 }
 
 func UpdateTask(c *gin.Context) {
-	id := c.Param("id")
-	if id == "" {
-		c.JSON(http.StatusBadRequest, model.ErrorResp(400, "missing task id"))
+	id, ok := resolveTaskID(c)
+	if !ok {
 		return
 	}
 
@@ -148,6 +224,10 @@ func UpdateTask(c *gin.Context) {
 
 	task, err := service.UpdateTask(id, req)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidLink) || errors.Is(err, service.ErrInvalidParent) {
+			c.JSON(http.StatusBadRequest, model.ErrorResp(400, err.Error()))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, model.ErrorResp(500, "failed to update task: "+err.Error()))
 		return
 	}
@@ -156,9 +236,8 @@ func UpdateTask(c *gin.Context) {
 }
 
 func UpdateProgress(c *gin.Context) {
-	id := c.Param("id")
-	if id == "" {
-		c.JSON(http.StatusBadRequest, model.ErrorResp(400, "missing task id"))
+	id, ok := resolveTaskID(c)
+	if !ok {
 		return
 	}
 
@@ -177,8 +256,13 @@ func UpdateProgress(c *gin.Context) {
 }
 
 func GetDailySummary(c *gin.Context) {
+	loc, ok := requestLocation(c)
+	if !ok {
+		return
+	}
+
 	dateStr := c.Query("date") // Format: YYYY-MM-DD
-	summary, err := service.GetDailySummary(dateStr)
+	summary, err := service.GetDailySummary(dateStr, loc)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, model.ErrorResp(500, "failed to get summary: "+err.Error()))
 		return
@@ -187,9 +271,65 @@ func GetDailySummary(c *gin.Context) {
 	c.JSON(http.StatusOK, model.SuccessResp(summary))
 }
 
+// GetPeriodSummary accepts either an explicit from/to range or a period (week|month)
+// anchored on an optional date. format=markdown returns the rendered report instead of JSON.
+func GetPeriodSummary(c *gin.Context) {
+	loc, ok := requestLocation(c)
+	if !ok {
+		return
+	}
+
+	from, to := c.Query("from"), c.Query("to")
+	if period := c.Query("period"); period != "" {
+		anchor := time.Now().In(loc)
+		if d := c.Query("date"); d != "" {
+			t, err := time.ParseInLocation("2006-01-02", d, loc)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, model.ErrorResp(400, "invalid date: "+d))
+				return
+			}
+			anchor = t
+		}
+
+		var err error
+		from, to, err = service.PeriodRange(period, anchor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, model.ErrorResp(400, err.Error()))
+			return
+		}
+	}
+
+	summary, err := service.GetPeriodSummary(from, to, loc)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidDateRange) {
+			c.JSON(http.StatusBadRequest, model.ErrorResp(400, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.ErrorResp(500, "failed to get summary: "+err.Error()))
+		return
+	}
+
+	if c.Query("format") == "markdown" {
+		md, err := exporter.RenderPeriodMarkdown(summary)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, model.ErrorResp(500, "failed to render markdown: "+err.Error()))
+			return
+		}
+		c.Data(http.StatusOK, "text/markdown; charset=utf-8", md)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.SuccessResp(summary))
+}
+
 func GetDailyMarkdown(c *gin.Context) {
+	loc, ok := requestLocation(c)
+	if !ok {
+		return
+	}
+
 	dateStr := c.Query("date") // Format: YYYY-MM-DD
-	zipBytes, err := exporter.GenerateDailyMarkdown(dateStr)
+	zipBytes, err := exporter.GenerateDailyMarkdown(dateStr, loc)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, model.ErrorResp(500, "failed to generate markdown: "+err.Error()))
 		return
@@ -199,8 +339,238 @@ func GetDailyMarkdown(c *gin.Context) {
 	c.Data(http.StatusOK, "application/zip", zipBytes)
 }
 
+// ListExportFormats lists the formats GET /exports/:format accepts.
+func ListExportFormats(c *gin.Context) {
+	var list []model.ExportFormatInfo
+	for _, f := range exporter.Formats() {
+		list = append(list, model.ExportFormatInfo{
+			Name:        f.Name(),
+			Description: f.Description(),
+			Ext:         f.Ext(),
+			ContentType: f.ContentType(),
+			Zip:         f.Zip(),
+		})
+	}
+	c.JSON(http.StatusOK, model.SuccessResp(list))
+}
+
+// GetExport downloads the selected tasks in a registered format, as one file or a
+// zip archive of one file per task.
+func GetExport(c *gin.Context) {
+	loc, ok := requestLocation(c)
+	if !ok {
+		return
+	}
+
+	var req model.ExportReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResp(400, "invalid parameters: "+err.Error()))
+		return
+	}
+
+	f, err := exporter.Export(c.Param("format"), req, loc)
+	if err != nil {
+		if errors.Is(err, exporter.ErrUnknownFormat) {
+			c.JSON(http.StatusNotFound, model.ErrorResp(404, err.Error()))
+			return
+		}
+		if errors.Is(err, exporter.ErrInvalidOptions) {
+			c.JSON(http.StatusBadRequest, model.ErrorResp(400, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.ErrorResp(500, "failed to export: "+err.Error()))
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+f.Name)
+	c.Data(http.StatusOK, f.ContentType, f.Data)
+}
+
+// GetFullExport downloads the whole database as a JSON dump that POST /imports accepts.
+func GetFullExport(c *gin.Context) {
+	dump, err := service.ExportDump()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResp(500, "failed to export: "+err.Error()))
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=chronicle-"+dump.ExportedAt.Format("20060102-150405")+".json")
+	c.JSON(http.StatusOK, dump)
+}
+
+func ImportDump(c *gin.Context) {
+	var dump model.Dump
+	if err := c.ShouldBindJSON(&dump); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResp(400, "invalid parameters: "+err.Error()))
+		return
+	}
+
+	result, err := service.ImportDump(&dump, c.DefaultQuery("mode", model.ImportModeMerge))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidDump) || errors.Is(err, service.ErrUnsupportedImportMode) {
+			c.JSON(http.StatusBadRequest, model.ErrorResp(400, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.ErrorResp(500, "failed to import: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.SuccessResp(result))
+}
+
+// GetCSVExport downloads tasks (filtered like the list API) or worklogs as CSV.
+func GetCSVExport(c *gin.Context) {
+	loc, ok := requestLocation(c)
+	if !ok {
+		return
+	}
+
+	var req model.CSVExportReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResp(400, "invalid parameters: "+err.Error()))
+		return
+	}
+
+	data, err := exporter.ExportCSV(req, loc)
+	if err != nil {
+		if errors.Is(err, exporter.ErrInvalidOptions) || errors.Is(err, service.ErrInvalidDateRange) {
+			c.JSON(http.StatusBadRequest, model.ErrorResp(400, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.ErrorResp(500, "failed to export: "+err.Error()))
+		return
+	}
+
+	name := req.Type
+	if name == "" {
+		name = model.CSVTypeTasks
+	}
+	c.Header("Content-Disposition", "attachment; filename=chronicle-"+name+".csv")
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+}
+
+// ImportCSV imports the CSV request body. Bad rows are reported with their line
+// numbers in the result instead of failing the request.
+func ImportCSV(c *gin.Context) {
+	loc, ok := requestLocation(c)
+	if !ok {
+		return
+	}
+
+	mapping, err := importer.ParseColumnMapping(c.Query("map"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResp(400, err.Error()))
+		return
+	}
+
+	result, err := importer.ImportCSV(c.Request.Body, c.Query("type"), mapping, loc)
+	if err != nil {
+		if errors.Is(err, importer.ErrInvalidFile) {
+			c.JSON(http.StatusBadRequest, model.ErrorResp(400, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.ErrorResp(500, "failed to import: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.SuccessResp(result))
+}
+
+// ImportCommits adds worklogs for git commits to the tasks their messages
+// reference. The commits are read by the client, which has the repository.
+func ImportCommits(c *gin.Context) {
+	var req model.CommitImportReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResp(400, "invalid parameters: "+err.Error()))
+		return
+	}
+
+	result, err := importer.ImportCommits(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResp(500, "failed to import commits: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.SuccessResp(result))
+}
+
+// ImportTaskList imports a todo.txt file, Taskwarrior export, Markdown checklists or
+// GitHub, GitLab or Jira issues from the request body; with dry_run=true it only
+// reports what would be imported.
+func ImportTaskList(c *gin.Context) {
+	loc, ok := requestLocation(c)
+	if !ok {
+		return
+	}
+
+	var req model.TaskListImportReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResp(400, err.Error()))
+		return
+	}
+
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResp(400, err.Error()))
+		return
+	}
+
+	result, err := importer.ImportTaskList(data, c.Param("format"), req, loc)
+	if err != nil {
+		if errors.Is(err, importer.ErrUnknownFormat) {
+			c.JSON(http.StatusNotFound, model.ErrorResp(404, err.Error()))
+			return
+		}
+		if errors.Is(err, importer.ErrInvalidFile) {
+			c.JSON(http.StatusBadRequest, model.ErrorResp(400, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.ErrorResp(500, "failed to import: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.SuccessResp(result))
+}
+
+// GetCalendar serves the iCalendar feed of task deadlines. Calendar apps cannot send
+// headers, so the feed is protected by the secret token in its URL.
+func GetCalendar(c *gin.Context) {
+	token := config.GetCalendarToken()
+	if token == "" {
+		c.JSON(http.StatusNotFound, model.ErrorResp(404, "calendar feed is disabled: set calendar_token to enable it"))
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(c.Query("token")), []byte(token)) != 1 {
+		c.JSON(http.StatusForbidden, model.ErrorResp(403, "invalid calendar token"))
+		return
+	}
+
+	var req model.CalendarReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResp(400, "invalid parameters: "+err.Error()))
+		return
+	}
+
+	data, err := exporter.GenerateICS(req)
+	if err != nil {
+		if errors.Is(err, exporter.ErrInvalidOptions) {
+			c.JSON(http.StatusBadRequest, model.ErrorResp(400, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.ErrorResp(500, "failed to generate calendar: "+err.Error()))
+		return
+	}
+
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", data)
+}
+
 func GetStatsSummary(c *gin.Context) {
-	summary, err := service.GetStatsSummary()
+	loc, ok := requestLocation(c)
+	if !ok {
+		return
+	}
+
+	summary, err := service.GetStatsSummary(loc)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, model.ErrorResp(500, "failed to get stats: "+err.Error()))
 		return
@@ -209,6 +579,146 @@ func GetStatsSummary(c *gin.Context) {
 	c.JSON(http.StatusOK, model.SuccessResp(summary))
 }
 
+func GetFlowStats(c *gin.Context) {
+	weeks := 0
+	if w := c.Query("weeks"); w != "" {
+		n, err := strconv.Atoi(w)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, model.ErrorResp(400, "invalid weeks: "+w))
+			return
+		}
+		weeks = n
+	}
+
+	loc, ok := requestLocation(c)
+	if !ok {
+		return
+	}
+
+	flow, err := service.GetFlowStats(weeks, c.Query("category"), loc)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResp(500, "failed to get flow stats: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.SuccessResp(flow))
+}
+
+// seriesFilter reads the task selection shared by the cumulative flow and burndown
+// endpoints.
+func seriesFilter(c *gin.Context) service.SeriesFilter {
+	return service.SeriesFilter{
+		Category: c.Query("category"),
+		Tag:      c.Query("tag"),
+		Parent:   c.Query("parent"),
+	}
+}
+
+func GetCumulativeFlow(c *gin.Context) {
+	loc, ok := requestLocation(c)
+	if !ok {
+		return
+	}
+
+	cfd, err := service.GetCumulativeFlow(c.Query("from"), c.Query("to"), seriesFilter(c), loc)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidDateRange) || errors.Is(err, service.ErrTaskNotFound) || errors.Is(err, service.ErrAmbiguousTaskID) {
+			c.JSON(http.StatusBadRequest, model.ErrorResp(400, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.ErrorResp(500, "failed to get cumulative flow: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.SuccessResp(cfd))
+}
+
+func GetBurndown(c *gin.Context) {
+	loc, ok := requestLocation(c)
+	if !ok {
+		return
+	}
+
+	burndown, err := service.GetBurndown(c.Query("from"), c.Query("to"), seriesFilter(c), loc)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidDateRange) || errors.Is(err, service.ErrTaskNotFound) || errors.Is(err, service.ErrAmbiguousTaskID) {
+			c.JSON(http.StatusBadRequest, model.ErrorResp(400, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.ErrorResp(500, "failed to get burndown: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.SuccessResp(burndown))
+}
+
+func GetWorklogHeatmap(c *gin.Context) {
+	days := 0
+	if d := c.Query("days"); d != "" {
+		n, err := strconv.Atoi(d)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, model.ErrorResp(400, "invalid days: "+d))
+			return
+		}
+		days = n
+	}
+
+	loc, ok := requestLocation(c)
+	if !ok {
+		return
+	}
+
+	heatmap, err := service.GetWorklogHeatmap(days, loc)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResp(500, "failed to get heatmap: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.SuccessResp(heatmap))
+}
+
+// GetAgentContext returns the prioritised snapshot of open work for agents. It
+// defaults to the plain text the budget is measured on, so what an agent reads by
+// default fits the budget; format=json returns the larger structured form.
+func GetAgentContext(c *gin.Context) {
+	format := c.DefaultQuery("format", "text")
+	if format != "text" && format != "json" {
+		c.JSON(http.StatusBadRequest, model.ErrorResp(400, "invalid format: "+format+" (expected text or json)"))
+		return
+	}
+
+	budget := model.DefaultContextBudget
+	if b := c.Query("budget"); b != "" {
+		n, err := strconv.Atoi(b)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, model.ErrorResp(400, "invalid budget: "+b))
+			return
+		}
+		budget = n
+	}
+
+	loc, ok := requestLocation(c)
+	if !ok {
+		return
+	}
+
+	snapshot, err := service.GetAgentContext(budget, time.Now(), loc)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidBudget) {
+			c.JSON(http.StatusBadRequest, model.ErrorResp(400, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.ErrorResp(500, "failed to get agent context: "+err.Error()))
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, model.SuccessResp(snapshot))
+		return
+	}
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(snapshot.Text()))
+}
+
 func GetArchivedTasks(c *gin.Context) {
 	tasks, err := service.GetArchivedTasks()
 	if err != nil {
@@ -219,9 +729,8 @@ func GetArchivedTasks(c *gin.Context) {
 }
 
 func ArchiveTask(c *gin.Context) {
-	id := c.Param("id")
-	if id == "" {
-		c.JSON(http.StatusBadRequest, model.ErrorResp(400, "missing task id"))
+	id, ok := resolveTaskID(c)
+	if !ok {
 		return
 	}
 
@@ -234,9 +743,8 @@ func ArchiveTask(c *gin.Context) {
 }
 
 func UnarchiveTask(c *gin.Context) {
-	id := c.Param("id")
-	if id == "" {
-		c.JSON(http.StatusBadRequest, model.ErrorResp(400, "missing task id"))
+	id, ok := resolveTaskID(c)
+	if !ok {
 		return
 	}
 
@@ -0,0 +1,305 @@
+// Package importer turns files produced by other tools into chronicle tasks and
+// worklogs. Rows that cannot be imported are reported with their line number
+// instead of failing the whole file.
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yuyudeqiu/chronicle/internal/exporter"
+	"github.com/yuyudeqiu/chronicle/internal/model"
+	"github.com/yuyudeqiu/chronicle/internal/service"
+)
+
+// ErrInvalidFile is returned when a file cannot be imported at all, e.g. a CSV file
+// without a required column.
+var ErrInvalidFile = errors.New("invalid import file")
+
+// csvTimeLayouts are tried in order when parsing CSV timestamps.
+var csvTimeLayouts = []string{exporter.CSVTimeLayout, time.RFC3339, "2006-01-02 15:04", "2006-01-02"}
+
+// ParseColumnMapping parses "field=Header,field=Header" into a map from chronicle
+// field names to the CSV headers that hold them.
+func ParseColumnMapping(s string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	if strings.TrimSpace(s) == "" {
+		return mapping, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		field, header, ok := strings.Cut(pair, "=")
+		field, header = strings.TrimSpace(field), strings.TrimSpace(header)
+		if !ok || field == "" || header == "" {
+			return nil, fmt.Errorf("%w: invalid column mapping %q (expected field=Header)", ErrInvalidFile, pair)
+		}
+		mapping[field] = header
+	}
+	return mapping, nil
+}
+
+// ImportCSV imports tasks or worklogs from CSV. Columns are matched to fields by
+// header name (case-insensitive), or through mapping for files with other headers.
+// Timestamps without an offset are read in loc.
+//
+// Tasks keep their id if the file has one; a task whose id already exists is
+// replaced only if the row's updated_at is newer. Worklogs must reference an
+// existing task.
+func ImportCSV(r io.Reader, kind string, mapping map[string]string, loc *time.Location) (*model.FileImportResult, error) {
+	var columns, required []string
+	switch kind {
+	case "", model.CSVTypeTasks:
+		kind = model.CSVTypeTasks
+		columns, required = exporter.TaskCSVColumns, []string{"title", "category"}
+	case model.CSVTypeWorklogs:
+		columns, required = exporter.WorklogCSVColumns, []string{"task_id", "log_text"}
+	default:
+		return nil, fmt.Errorf("%w: unsupported type %q (expected %s or %s)", ErrInvalidFile, kind, model.CSVTypeTasks, model.CSVTypeWorklogs)
+	}
+	for field := range mapping {
+		if !contains(columns, field) {
+			return nil, fmt.Errorf("%w: unknown field %q in column mapping (available: %s)", ErrInvalidFile, field, strings.Join(columns, ", "))
+		}
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	index := make(map[string]int)
+	for _, field := range columns {
+		name := field
+		if h, ok := mapping[field]; ok {
+			name = h
+		}
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), name) {
+				index[field] = i
+				break
+			}
+		}
+	}
+	for _, field := range required {
+		if _, ok := index[field]; !ok {
+			return nil, fmt.Errorf("%w: missing required column %q", ErrInvalidFile, field)
+		}
+	}
+
+	var rows []csvRow
+	result := &model.FileImportResult{Errors: []model.ImportRowError{}}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				result.Errors = append(result.Errors, model.ImportRowError{Line: parseErr.StartLine, Error: parseErr.Err.Error()})
+				continue
+			}
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		row := csvRow{line: line, values: make(map[string]string)}
+		for field, i := range index {
+			if i < len(record) {
+				row.values[field] = strings.TrimSpace(record[i])
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	dump := &model.Dump{Format: model.DumpFormat, Version: model.DumpVersion}
+	seen := make(map[string]int)
+	duplicate := func(line int, id string) bool {
+		if first, ok := seen[id]; ok {
+			result.Errors = append(result.Errors, model.ImportRowError{Line: line, Error: fmt.Sprintf("duplicate id %s (first on line %d)", id, first)})
+			return true
+		}
+		seen[id] = line
+		return false
+	}
+
+	if kind == model.CSVTypeTasks {
+		for _, row := range rows {
+			task, err := parseTaskRow(row, loc)
+			if err != nil {
+				result.Errors = append(result.Errors, model.ImportRowError{Line: row.line, Error: err.Error()})
+				continue
+			}
+			if duplicate(row.line, task.ID) {
+				continue
+			}
+			dump.Tasks = append(dump.Tasks, task)
+		}
+	} else {
+		var ids []string
+		for _, row := range rows {
+			ids = append(ids, row.values["task_id"])
+		}
+		tasks, err := service.GetTasksByID(ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			l, err := parseWorklogRow(row, tasks, loc)
+			if err != nil {
+				result.Errors = append(result.Errors, model.ImportRowError{Line: row.line, Error: err.Error()})
+				continue
+			}
+			if duplicate(row.line, l.ID) {
+				continue
+			}
+			dump.Logs = append(dump.Logs, l)
+		}
+	}
+
+	imported, err := importRows(dump, result, false)
+	if err != nil {
+		return nil, err
+	}
+	result.ImportResult = *imported
+	sort.SliceStable(result.Errors, func(i, j int) bool { return result.Errors[i].Line < result.Errors[j].Line })
+	return result, nil
+}
+
+type csvRow struct {
+	line   int
+	values map[string]string
+}
+
+func parseTaskRow(row csvRow, loc *time.Location) (model.Task, error) {
+	v := row.values
+	now := time.Now()
+	t := model.Task{
+		ID:          v["id"],
+		Title:       v["title"],
+		Category:    v["category"],
+		Status:      v["status"],
+		Description: v["description"],
+		Targets:     v["targets"],
+		Links:       model.ParseLinks(v["links"]),
+		ExternalRef: v["external_ref"],
+		CreatedAt:   now,
+	}
+	if t.Title == "" || t.Category == "" {
+		return t, errors.New("title and category are required")
+	}
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	if t.Status == "" {
+		t.Status = model.TaskStatusTodo
+	}
+	if t.Status != model.TaskStatusTodo && t.Status != model.TaskStatusInProgress && t.Status != model.TaskStatusDone {
+		return t, fmt.Errorf("invalid status %q", t.Status)
+	}
+
+	var err error
+	if t.Deadline, err = parseOptionalTime(v, "deadline", loc); err != nil {
+		return t, err
+	}
+	if t.ActualCompletedAt, err = parseOptionalTime(v, "actual_completed_at", loc); err != nil {
+		return t, err
+	}
+	if t.ArchivedAt, err = parseOptionalTime(v, "archived_at", loc); err != nil {
+		return t, err
+	}
+	if created, err := parseOptionalTime(v, "created_at", loc); err != nil {
+		return t, err
+	} else if created != nil {
+		t.CreatedAt = *created
+	}
+	t.UpdatedAt = t.CreatedAt
+	if updated, err := parseOptionalTime(v, "updated_at", loc); err != nil {
+		return t, err
+	} else if updated != nil {
+		t.UpdatedAt = *updated
+	}
+	if t.Status == model.TaskStatusDone && t.ActualCompletedAt == nil {
+		completed := t.UpdatedAt
+		t.ActualCompletedAt = &completed
+	}
+	return t, nil
+}
+
+func parseWorklogRow(row csvRow, tasks map[string]model.Task, loc *time.Location) (model.TaskLog, error) {
+	v := row.values
+	l := model.TaskLog{
+		ID:           v["id"],
+		TaskID:       v["task_id"],
+		LogText:      v["log_text"],
+		ProgressNote: v["progress_note"],
+		CreatedAt:    time.Now(),
+	}
+	if l.TaskID == "" || l.LogText == "" {
+		return l, errors.New("task_id and log_text are required")
+	}
+	if _, ok := tasks[l.TaskID]; !ok {
+		return l, fmt.Errorf("task %s not found", l.TaskID)
+	}
+	if l.ID == "" {
+		l.ID = uuid.New().String()
+	}
+	if created, err := parseOptionalTime(v, "created_at", loc); err != nil {
+		return l, err
+	} else if created != nil {
+		l.CreatedAt = *created
+	}
+	return l, nil
+}
+
+// importRows writes the valid rows through the dump importer in merge mode, which
+// validates them once more and keeps existing records. Should that validation still
+// fail, nothing is imported and the problem is reported without a line number. A dry
+// run only counts what would be written.
+func importRows(dump *model.Dump, result *model.FileImportResult, dryRun bool) (*model.ImportResult, error) {
+	importDump := service.ImportDump
+	if dryRun {
+		importDump = service.DryRunImport
+	}
+	imported, err := importDump(dump, model.ImportModeMerge)
+	if errors.Is(err, service.ErrInvalidDump) {
+		result.Errors = append(result.Errors, model.ImportRowError{Error: err.Error()})
+		return &model.ImportResult{Mode: model.ImportModeMerge}, nil
+	}
+	return imported, err
+}
+
+func parseOptionalTime(values map[string]string, field string, loc *time.Location) (*time.Time, error) {
+	s := values[field]
+	if s == "" {
+		return nil, nil
+	}
+	for _, layout := range csvTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid %s %q (expected YYYY-MM-DD HH:MM:SS, RFC 3339 or YYYY-MM-DD)", field, s)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,178 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yuyudeqiu/chronicle/internal/model"
+	"github.com/yuyudeqiu/chronicle/internal/service"
+)
+
+// gitRefPattern matches the task references of a commit message: "chronicle:"
+// followed by one or more short ids, full ids or id prefixes separated by commas,
+// e.g. "chronicle: #12" or the trailer "Chronicle: 3f2b9c1e, #7".
+var gitRefPattern = regexp.MustCompile(`(?i)\bchronicle:[ \t]*((?:#\d+|[0-9a-f][0-9a-f-]{3,35})(?:[ \t]*,[ \t]*(?:#\d+|[0-9a-f][0-9a-f-]{3,35}))*)\b`)
+
+// gitLogFormat separates the fields of a commit with US and commits with RS.
+const gitLogFormat = "--format=%H%x1f%an%x1f%aI%x1f%s%x1f%b%x1e"
+
+// CommitTaskRefs returns the task ids referenced by a commit message, in order and
+// without duplicates.
+func CommitTaskRefs(message string) []string {
+	var refs []string
+	seen := make(map[string]bool)
+	for _, m := range gitRefPattern.FindAllStringSubmatch(message, -1) {
+		for _, ref := range strings.Split(m[1], ",") {
+			ref = strings.ToLower(strings.TrimSpace(ref))
+			if !seen[ref] {
+				seen[ref] = true
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs
+}
+
+// ReadGitCommits reads the commits of the current branch of the git repository at
+// repo, oldest first, leaving out merges. since takes any date git understands
+// (e.g. 2026-03-01 or "2 weeks ago"); limit keeps only the newest commits. Both
+// are ignored when empty or zero.
+func ReadGitCommits(repo, since string, limit int) ([]model.GitCommit, error) {
+	args := []string{"-C", repo, "log", "--no-merges", gitLogFormat}
+	if since != "" {
+		args = append(args, "--since="+since)
+	}
+	if limit > 0 {
+		args = append(args, fmt.Sprintf("--max-count=%d", limit))
+	}
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("git log in %s: %s", repo, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("git log in %s: %w", repo, err)
+	}
+
+	var commits []model.GitCommit
+	for _, record := range strings.Split(string(out), "\x1e") {
+		fields := strings.Split(strings.TrimLeft(record, "\n"), "\x1f")
+		if len(fields) != 5 {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("git log in %s: commit %s has invalid date %q", repo, fields[0], fields[2])
+		}
+		commits = append(commits, model.GitCommit{
+			Hash:    fields[0],
+			Author:  fields[1],
+			Time:    at,
+			Subject: fields[3],
+			Body:    strings.TrimSpace(fields[4]),
+		})
+	}
+	// git log lists the newest first
+	for i, j := 0, len(commits)-1; i < j; i, j = i+1, j-1 {
+		commits[i], commits[j] = commits[j], commits[i]
+	}
+	return commits, nil
+}
+
+// ImportCommits adds a worklog with the subject and hash of each commit to every
+// task its message references. The worklog id is derived from the commit and task,
+// so scanning the same commits again adds nothing. References that match no task or
+// several are reported instead.
+func ImportCommits(req model.CommitImportReq) (*model.CommitImportResult, error) {
+	result := &model.CommitImportResult{
+		DryRun:  req.DryRun,
+		Commits: len(req.Commits),
+		Logs:    []model.CommitLog{},
+		Errors:  []model.CommitRefError{},
+	}
+
+	resolved := make(map[string]string)
+	failed := make(map[string]error)
+	dump := &model.Dump{Format: model.DumpFormat, Version: model.DumpVersion}
+	var taskIDs, logIDs []string
+	seen := make(map[string]bool)
+	for _, c := range req.Commits {
+		if c.Hash == "" {
+			continue
+		}
+		for _, ref := range CommitTaskRefs(c.Subject + "\n" + c.Body) {
+			id, ok := resolved[ref]
+			if !ok && failed[ref] == nil {
+				var err error
+				id, err = service.ResolveTaskID(ref)
+				if err != nil && !errors.Is(err, service.ErrTaskNotFound) && !errors.Is(err, service.ErrAmbiguousTaskID) {
+					return nil, err
+				}
+				if err != nil {
+					failed[ref] = err
+				} else {
+					resolved[ref] = id
+				}
+			}
+			if err := failed[ref]; err != nil {
+				result.Errors = append(result.Errors, model.CommitRefError{Hash: c.Hash, Ref: ref, Error: err.Error()})
+				continue
+			}
+
+			logID := uuid.NewSHA1(uuid.NameSpaceURL, []byte("chronicle:git:"+c.Hash+":"+id)).String()
+			if seen[logID] {
+				continue
+			}
+			seen[logID] = true
+			at := c.Time
+			if at.IsZero() {
+				at = time.Now()
+			}
+			dump.Logs = append(dump.Logs, model.TaskLog{
+				ID:        logID,
+				TaskID:    id,
+				LogText:   fmt.Sprintf("%s (commit %s)", c.Subject, shortHash(c.Hash)),
+				CreatedAt: at,
+			})
+			result.Logs = append(result.Logs, model.CommitLog{Hash: c.Hash, TaskID: id, LogID: logID})
+			taskIDs = append(taskIDs, id)
+			logIDs = append(logIDs, logID)
+		}
+	}
+
+	tasks, err := service.GetTasksByID(taskIDs)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := service.GetWorklogsByID(logIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range result.Logs {
+		l := &result.Logs[i]
+		l.TaskTitle = tasks[l.TaskID].Title
+		_, logged := existing[l.LogID]
+		l.Created = !logged
+	}
+
+	if len(dump.Logs) == 0 || req.DryRun {
+		return result, nil
+	}
+	if _, err := service.ImportDump(dump, model.ImportModeMerge); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// shortHash abbreviates a commit hash the way git usually shows it.
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
@@ -0,0 +1,174 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yuyudeqiu/chronicle/internal/model"
+	"github.com/yuyudeqiu/chronicle/internal/service"
+)
+
+// setupDB points service.DB at a fresh, migrated in-memory SQLite database for the
+// duration of the test.
+func setupDB(t *testing.T) {
+	t.Helper()
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(t.Name())
+	db, err := service.OpenDB(service.DriverSQLite, fmt.Sprintf("file:%s?mode=memory&cache=shared", name))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("get connection pool: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if _, err := service.MigrateUp(db, 0); err != nil {
+		t.Fatalf("migrate database: %v", err)
+	}
+
+	prev := service.DB
+	service.DB = db
+	t.Cleanup(func() {
+		service.DB = prev
+		sqlDB.Close()
+	})
+}
+
+// gitRepo creates an empty repository in a temporary directory and returns a
+// function that commits with the given message.
+func gitRepo(t *testing.T) (string, func(message string)) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	n := 0
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		date := fmt.Sprintf("2026-03-10T09:%02d:00+08:00", n)
+		cmd.Env = append(os.Environ(),
+			"GIT_CONFIG_GLOBAL="+filepath.Join(dir, ".gitconfig-none"), "GIT_CONFIG_NOSYSTEM=1",
+			"GIT_AUTHOR_NAME=Tester", "GIT_AUTHOR_EMAIL=tester@example.com", "GIT_AUTHOR_DATE="+date,
+			"GIT_COMMITTER_NAME=Tester", "GIT_COMMITTER_EMAIL=tester@example.com", "GIT_COMMITTER_DATE="+date,
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	git("init", "-q")
+	return dir, func(message string) {
+		t.Helper()
+		n++
+		git("commit", "-q", "--allow-empty", "-m", message)
+	}
+}
+
+func TestCommitTaskRefs(t *testing.T) {
+	tests := []struct {
+		message string
+		want    []string
+	}{
+		{"Fix parser\n\nchronicle: #12", []string{"#12"}},
+		{"Refactor\n\nChronicle: 3F2B9C1E, #7,#12\nchronicle: #7", []string{"3f2b9c1e", "#7", "#12"}},
+		{"Subject chronicle:#3 and chronicle: #4", []string{"#3", "#4"}},
+		{"No references here", nil},
+		{"chronicle: later", nil},
+	}
+	for _, tt := range tests {
+		if got := CommitTaskRefs(tt.message); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("CommitTaskRefs(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestImportCommitsFromRepository(t *testing.T) {
+	setupDB(t)
+	first, err := service.CreateTask(model.CreateTaskReq{Title: "first", Category: "dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := service.CreateTask(model.CreateTaskReq{Title: "second", Category: "dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo, commit := gitRepo(t)
+	commit("Fix parser\n\nchronicle: #1")
+	commit("Share helpers\n\nChronicle: #1, " + second.ID[:8])
+	commit("Unrelated change")
+	commit("Fix typo\n\nchronicle: #99")
+
+	commits, err := ReadGitCommits(repo, "", 0)
+	if err != nil {
+		t.Fatalf("ReadGitCommits: %v", err)
+	}
+	var subjects []string
+	for _, c := range commits {
+		subjects = append(subjects, c.Subject)
+	}
+	if want := []string{"Fix parser", "Share helpers", "Unrelated change", "Fix typo"}; !reflect.DeepEqual(subjects, want) {
+		t.Fatalf("commits = %q, want %q (oldest first)", subjects, want)
+	}
+	if commits[0].Author != "Tester" || commits[0].Body != "chronicle: #1" || commits[0].Time.Hour() != 9 {
+		t.Errorf("first commit = %+v", commits[0])
+	}
+	if newest, err := ReadGitCommits(repo, "", 1); err != nil || len(newest) != 1 || newest[0].Subject != "Fix typo" {
+		t.Errorf("ReadGitCommits with limit 1 = %v, %v; want only the newest commit", newest, err)
+	}
+
+	result, err := ImportCommits(model.CommitImportReq{Commits: commits})
+	if err != nil {
+		t.Fatalf("ImportCommits: %v", err)
+	}
+	type logged struct{ subject, task string }
+	var got []logged
+	bySubject := make(map[string]string)
+	for _, c := range commits {
+		bySubject[c.Hash] = c.Subject
+	}
+	for _, l := range result.Logs {
+		if !l.Created {
+			t.Errorf("first scan reported %s on %s as already logged", l.Hash, l.TaskID)
+		}
+		got = append(got, logged{bySubject[l.Hash], l.TaskID})
+	}
+	want := []logged{{"Fix parser", first.ID}, {"Share helpers", first.ID}, {"Share helpers", second.ID}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("logs = %v, want one per commit and task: %v", got, want)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Ref != "#99" || result.Errors[0].Hash != commits[3].Hash {
+		t.Errorf("errors = %+v, want the unknown ref #99 of the last commit", result.Errors)
+	}
+	assertWorklogs(t, first.ID, 2)
+	assertWorklogs(t, second.ID, 1)
+
+	again, err := ImportCommits(model.CommitImportReq{Commits: commits})
+	if err != nil {
+		t.Fatalf("second ImportCommits: %v", err)
+	}
+	for _, l := range again.Logs {
+		if l.Created {
+			t.Errorf("second scan logged %s on %s again", l.Hash, l.TaskID)
+		}
+	}
+	assertWorklogs(t, first.ID, 2)
+	assertWorklogs(t, second.ID, 1)
+}
+
+func assertWorklogs(t *testing.T, taskID string, want int) {
+	t.Helper()
+	task, err := service.GetTask(taskID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if len(task.Logs) != want {
+		t.Errorf("task %s has %d worklogs, want %d", task.Title, len(task.Logs), want)
+	}
+}
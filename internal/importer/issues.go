@@ -0,0 +1,521 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yuyudeqiu/chronicle/internal/model"
+)
+
+var (
+	// githubIssuePattern matches the web and API URLs of a GitHub issue.
+	githubIssuePattern = regexp.MustCompile(`([^/]+)/([^/]+)/issues/(\d+)/?$`)
+	// gitlabIssuePattern matches the web URL of a GitLab issue.
+	gitlabIssuePattern = regexp.MustCompile(`^https?://[^/]+/(.+)/-/issues/(\d+)/?$`)
+)
+
+// jiraTimeLayouts are the timestamp formats of the Jira REST API.
+var jiraTimeLayouts = []string{"2006-01-02T15:04:05.000-0700", time.RFC3339}
+
+// issueLabels reads labels given as names or as objects with a name.
+type issueLabels []string
+
+func (l *issueLabels) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for _, r := range raw {
+		var label struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(r, &label.Name); err != nil {
+			if err := json.Unmarshal(r, &label); err != nil {
+				return err
+			}
+		}
+		*l = append(*l, label.Name)
+	}
+	return nil
+}
+
+// ghComment is an issue comment of the GitHub REST API.
+type ghComment struct {
+	ID       int64  `json:"id"`
+	IssueURL string `json:"issue_url"`
+	Body     string `json:"body"`
+	User     struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ghIssue is an issue of the GitHub REST API.
+type ghIssue struct {
+	Number    int         `json:"number"`
+	Title     string      `json:"title"`
+	Body      string      `json:"body"`
+	State     string      `json:"state"`
+	URL       string      `json:"url"`
+	HTMLURL   string      `json:"html_url"`
+	Labels    issueLabels `json:"labels"`
+	Milestone *struct {
+		DueOn *time.Time `json:"due_on"`
+	} `json:"milestone"`
+	PullRequest json.RawMessage `json:"pull_request"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	ClosedAt    *time.Time      `json:"closed_at"`
+	// Comments is a count in the API; an array of comments is read as well.
+	Comments json.RawMessage `json:"comments"`
+}
+
+// parseGitHubIssues reads issues of the GitHub REST API (GET /repos/{owner}/{repo}/issues).
+// Comments (GET /repos/{owner}/{repo}/issues/comments) can be embedded in the
+// "comments" field of their issue or given in the same array. Pull requests are
+// skipped.
+func parseGitHubIssues(data []byte) ([]listItem, []model.ImportRowError, error) {
+	records, err := readJSONRecords(data, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var items []listItem
+	errs := []model.ImportRowError{}
+	for _, r := range records {
+		var probe struct {
+			Number   int    `json:"number"`
+			IssueURL string `json:"issue_url"`
+		}
+		if err := json.Unmarshal(r.raw, &probe); err != nil {
+			return nil, nil, fmt.Errorf("%w: line %d: %v", ErrInvalidFile, r.line, err)
+		}
+
+		var it listItem
+		if probe.Number == 0 && probe.IssueURL != "" {
+			var c ghComment
+			if err := json.Unmarshal(r.raw, &c); err != nil {
+				return nil, nil, fmt.Errorf("%w: line %d: %v", ErrInvalidFile, r.line, err)
+			}
+			it, err = parseGitHubComment(c)
+		} else {
+			var gh ghIssue
+			if err := json.Unmarshal(r.raw, &gh); err != nil {
+				return nil, nil, fmt.Errorf("%w: line %d: %v", ErrInvalidFile, r.line, err)
+			}
+			it, err = parseGitHubIssue(gh)
+		}
+		if err != nil {
+			errs = append(errs, model.ImportRowError{Line: r.line, Error: err.Error()})
+			continue
+		}
+		it.line = r.line
+		items = append(items, it)
+	}
+	return items, errs, nil
+}
+
+func parseGitHubIssue(gh ghIssue) (listItem, error) {
+	var it listItem
+	if len(gh.PullRequest) > 0 && string(gh.PullRequest) != "null" {
+		return it, fmt.Errorf("pull request #%d skipped", gh.Number)
+	}
+	link := gh.HTMLURL
+	if link == "" {
+		link = gh.URL
+	}
+	m := githubIssuePattern.FindStringSubmatch(link)
+	if m == nil {
+		return it, fmt.Errorf("issue #%d has no issue url", gh.Number)
+	}
+	if gh.Title == "" {
+		return it, fmt.Errorf("issue #%d has no title", gh.Number)
+	}
+
+	ref := "github:" + m[1] + "/" + m[2] + "#" + m[3]
+	it.task = model.Task{
+		Title:       gh.Title,
+		Category:    m[2],
+		Description: strings.TrimSpace(gh.Body),
+		Links:       issueLinks(gh.HTMLURL, ref),
+		ExternalRef: ref,
+	}
+	switch gh.State {
+	case "open":
+		it.task.Status = model.TaskStatusTodo
+	case "closed":
+		it.task.Status = model.TaskStatusDone
+		it.task.ActualCompletedAt = gh.ClosedAt
+	default:
+		return it, fmt.Errorf("issue #%d has invalid state %q", gh.Number, gh.State)
+	}
+	if gh.Milestone != nil {
+		it.task.Deadline = gh.Milestone.DueOn
+	}
+	it.created, it.stamp = gh.CreatedAt, gh.UpdatedAt
+	it.tags = gh.Labels
+
+	var comments []ghComment
+	if json.Unmarshal(gh.Comments, &comments) == nil {
+		for _, c := range comments {
+			if l, ok := issueCommentLog(ref, strconv.FormatInt(c.ID, 10), c.User.Login, c.Body, c.CreatedAt); ok {
+				it.logs = append(it.logs, l)
+			}
+		}
+	}
+	return it, nil
+}
+
+// parseGitHubComment reads a comment given apart from its issue.
+func parseGitHubComment(c ghComment) (listItem, error) {
+	var it listItem
+	m := githubIssuePattern.FindStringSubmatch(c.IssueURL)
+	if m == nil {
+		return it, fmt.Errorf("comment %d has an invalid issue_url %q", c.ID, c.IssueURL)
+	}
+	it.commentsOnly = true
+	it.task.ExternalRef = "github:" + m[1] + "/" + m[2] + "#" + m[3]
+	if l, ok := issueCommentLog(it.task.ExternalRef, strconv.FormatInt(c.ID, 10), c.User.Login, c.Body, c.CreatedAt); ok {
+		it.logs = append(it.logs, l)
+	}
+	return it, nil
+}
+
+// glIssue is an issue of the GitLab REST API.
+type glIssue struct {
+	IID         int    `json:"iid"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	State       string `json:"state"`
+	WebURL      string `json:"web_url"`
+	References  struct {
+		Full string `json:"full"`
+	} `json:"references"`
+	Labels    issueLabels `json:"labels"`
+	DueDate   string      `json:"due_date"`
+	Milestone *struct {
+		DueDate string `json:"due_date"`
+	} `json:"milestone"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	ClosedAt  *time.Time `json:"closed_at"`
+	// Notes are not part of the issue in the API; they are read if embedded.
+	Notes []struct {
+		ID     int64  `json:"id"`
+		Body   string `json:"body"`
+		System bool   `json:"system"`
+		Author struct {
+			Username string `json:"username"`
+		} `json:"author"`
+		CreatedAt time.Time `json:"created_at"`
+	} `json:"notes"`
+}
+
+// parseGitLabIssues reads issues of the GitLab REST API (GET /projects/:id/issues).
+// Notes (GET /projects/:id/issues/:iid/notes) are imported as worklogs when they are
+// embedded in the "notes" field of their issue; system notes are skipped.
+func parseGitLabIssues(data []byte, loc *time.Location) ([]listItem, []model.ImportRowError, error) {
+	records, err := readJSONRecords(data, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var items []listItem
+	errs := []model.ImportRowError{}
+	for _, r := range records {
+		var gl glIssue
+		if err := json.Unmarshal(r.raw, &gl); err != nil {
+			return nil, nil, fmt.Errorf("%w: line %d: %v", ErrInvalidFile, r.line, err)
+		}
+		it, err := parseGitLabIssue(gl, loc)
+		if err != nil {
+			errs = append(errs, model.ImportRowError{Line: r.line, Error: err.Error()})
+			continue
+		}
+		it.line = r.line
+		items = append(items, it)
+	}
+	return items, errs, nil
+}
+
+func parseGitLabIssue(gl glIssue, loc *time.Location) (listItem, error) {
+	var it listItem
+	project, _, _ := strings.Cut(gl.References.Full, "#")
+	if m := gitlabIssuePattern.FindStringSubmatch(gl.WebURL); project == "" && m != nil {
+		project = m[1]
+	}
+	if project == "" || gl.IID == 0 {
+		return it, fmt.Errorf("issue %q has no project or iid", gl.Title)
+	}
+	if gl.Title == "" {
+		return it, fmt.Errorf("issue #%d has no title", gl.IID)
+	}
+
+	ref := fmt.Sprintf("gitlab:%s#%d", project, gl.IID)
+	it.task = model.Task{
+		Title:       gl.Title,
+		Category:    project[strings.LastIndex(project, "/")+1:],
+		Description: strings.TrimSpace(gl.Description),
+		Links:       issueLinks(gl.WebURL, ref),
+		ExternalRef: ref,
+	}
+	switch gl.State {
+	case "opened", "reopened", "locked":
+		it.task.Status = model.TaskStatusTodo
+	case "closed":
+		it.task.Status = model.TaskStatusDone
+		it.task.ActualCompletedAt = gl.ClosedAt
+	default:
+		return it, fmt.Errorf("issue #%d has invalid state %q", gl.IID, gl.State)
+	}
+	due := gl.DueDate
+	if due == "" && gl.Milestone != nil {
+		due = gl.Milestone.DueDate
+	}
+	if due != "" {
+		day, ok := parseListDate(due, loc)
+		if !ok {
+			return it, fmt.Errorf("issue #%d has invalid due date %q", gl.IID, due)
+		}
+		it.task.Deadline = endOfDay(day)
+	}
+	it.created, it.stamp = gl.CreatedAt, gl.UpdatedAt
+	it.tags = gl.Labels
+
+	for _, n := range gl.Notes {
+		if n.System {
+			continue
+		}
+		if l, ok := issueCommentLog(ref, strconv.FormatInt(n.ID, 10), n.Author.Username, n.Body, n.CreatedAt); ok {
+			it.logs = append(it.logs, l)
+		}
+	}
+	return it, nil
+}
+
+// jiraIssue is an issue of the Jira REST API.
+type jiraIssue struct {
+	Key    string `json:"key"`
+	Self   string `json:"self"`
+	Fields struct {
+		Summary     string          `json:"summary"`
+		Description json.RawMessage `json:"description"`
+		Status      struct {
+			Name           string `json:"name"`
+			StatusCategory struct {
+				Key string `json:"key"`
+			} `json:"statusCategory"`
+		} `json:"status"`
+		Project struct {
+			Key  string `json:"key"`
+			Name string `json:"name"`
+		} `json:"project"`
+		Labels   []string `json:"labels"`
+		Priority *struct {
+			Name string `json:"name"`
+		} `json:"priority"`
+		DueDate        string `json:"duedate"`
+		Created        string `json:"created"`
+		Updated        string `json:"updated"`
+		ResolutionDate string `json:"resolutiondate"`
+		Comment        struct {
+			Comments []struct {
+				ID     string `json:"id"`
+				Author struct {
+					DisplayName string `json:"displayName"`
+				} `json:"author"`
+				Body    json.RawMessage `json:"body"`
+				Created string          `json:"created"`
+			} `json:"comments"`
+		} `json:"comment"`
+	} `json:"fields"`
+}
+
+// parseJiraIssues reads the result of a Jira issue search (GET /rest/api/2/search or
+// /rest/api/3/search), an array of issues or single issues. Descriptions and comments
+// may be plain text or Atlassian Document Format; projects become categories.
+func parseJiraIssues(data []byte, loc *time.Location) ([]listItem, []model.ImportRowError, error) {
+	records, err := readJSONRecords(data, "issues")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var items []listItem
+	errs := []model.ImportRowError{}
+	for _, r := range records {
+		var ji jiraIssue
+		if err := json.Unmarshal(r.raw, &ji); err != nil {
+			return nil, nil, fmt.Errorf("%w: line %d: %v", ErrInvalidFile, r.line, err)
+		}
+		it, err := parseJiraIssue(ji, loc)
+		if err != nil {
+			errs = append(errs, model.ImportRowError{Line: r.line, Error: err.Error()})
+			continue
+		}
+		it.line = r.line
+		items = append(items, it)
+	}
+	return items, errs, nil
+}
+
+func parseJiraIssue(ji jiraIssue, loc *time.Location) (listItem, error) {
+	var it listItem
+	f := ji.Fields
+	if ji.Key == "" {
+		return it, errors.New("issue has no key")
+	}
+	if f.Summary == "" {
+		return it, fmt.Errorf("issue %s has no summary", ji.Key)
+	}
+
+	ref := "jira:" + ji.Key
+	it.task = model.Task{
+		Title:       f.Summary,
+		Category:    f.Project.Name,
+		Description: jiraText(f.Description),
+		ExternalRef: ref,
+	}
+	if it.task.Category == "" {
+		it.task.Category = f.Project.Key
+	}
+	if u, err := url.Parse(ji.Self); err == nil && u.Host != "" {
+		it.task.Links = issueLinks(u.Scheme+"://"+u.Host+"/browse/"+ji.Key, ref)
+	}
+
+	times := make(map[string]*time.Time)
+	for name, s := range map[string]string{"created": f.Created, "updated": f.Updated, "resolutiondate": f.ResolutionDate} {
+		if s == "" {
+			continue
+		}
+		t, ok := parseJiraTime(s)
+		if !ok {
+			return it, fmt.Errorf("issue %s has invalid %s %q", ji.Key, name, s)
+		}
+		times[name] = &t
+	}
+	switch f.Status.StatusCategory.Key {
+	case "new", "":
+		it.task.Status = model.TaskStatusTodo
+	case "indeterminate":
+		it.task.Status = model.TaskStatusInProgress
+	case "done":
+		it.task.Status = model.TaskStatusDone
+		it.task.ActualCompletedAt = times["resolutiondate"]
+	default:
+		return it, fmt.Errorf("issue %s has unknown status category %q", ji.Key, f.Status.StatusCategory.Key)
+	}
+	if f.DueDate != "" {
+		day, ok := parseListDate(f.DueDate, loc)
+		if !ok {
+			return it, fmt.Errorf("issue %s has invalid due date %q", ji.Key, f.DueDate)
+		}
+		it.task.Deadline = endOfDay(day)
+	}
+	if t := times["created"]; t != nil {
+		it.created = *t
+	}
+	if t := times["updated"]; t != nil {
+		it.stamp = *t
+	}
+	if f.Priority != nil {
+		it.priority = f.Priority.Name
+	}
+	it.tags = f.Labels
+
+	for _, c := range f.Comment.Comments {
+		at, ok := parseJiraTime(c.Created)
+		if !ok {
+			return it, fmt.Errorf("issue %s has a comment with invalid created %q", ji.Key, c.Created)
+		}
+		if l, ok := issueCommentLog(ref, c.ID, c.Author.DisplayName, jiraText(c.Body), at); ok {
+			it.logs = append(it.logs, l)
+		}
+	}
+	return it, nil
+}
+
+func parseJiraTime(s string) (time.Time, bool) {
+	for _, layout := range jiraTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// adfNode is a node of the Atlassian Document Format.
+type adfNode struct {
+	Type    string    `json:"type"`
+	Text    string    `json:"text"`
+	Content []adfNode `json:"content"`
+}
+
+// jiraText returns the plain text of a Jira field, which is a string in API v2 and
+// an Atlassian Document Format document in v3.
+func jiraText(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return strings.TrimSpace(s)
+	}
+	var doc adfNode
+	if json.Unmarshal(raw, &doc) != nil {
+		return ""
+	}
+	var b strings.Builder
+	var walk func(n adfNode)
+	walk = func(n adfNode) {
+		switch n.Type {
+		case "text":
+			b.WriteString(n.Text)
+		case "hardBreak":
+			b.WriteString("\n")
+		case "listItem":
+			b.WriteString("- ")
+		}
+		for _, c := range n.Content {
+			walk(c)
+		}
+		switch n.Type {
+		case "paragraph", "heading", "codeBlock", "blockquote", "rule":
+			b.WriteString("\n")
+		}
+	}
+	walk(doc)
+	return strings.TrimSpace(b.String())
+}
+
+// issueLinks links a task to the page of the issue it was imported from.
+func issueLinks(pageURL, ref string) model.TaskLinks {
+	if pageURL == "" {
+		return nil
+	}
+	return model.TaskLinks{{URL: pageURL, Kind: model.LinkKindIssue, Label: ref}}
+}
+
+// issueCommentLog turns an issue comment into a worklog whose id is derived from the
+// issue and comment, so importing it again finds it. Empty comments are dropped.
+func issueCommentLog(ref, id, author, body string, at time.Time) (model.TaskLog, bool) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return model.TaskLog{}, false
+	}
+	if id == "" {
+		id = at.UTC().Format(time.RFC3339Nano) + ":" + body
+	}
+	text := body
+	if author != "" {
+		text = author + ": " + body
+	}
+	return model.TaskLog{
+		ID:        uuid.NewSHA1(uuid.NameSpaceURL, []byte("chronicle:"+ref+":comment:"+id)).String(),
+		LogText:   text,
+		CreatedAt: at,
+	}, true
+}
@@ -0,0 +1,164 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yuyudeqiu/chronicle/internal/model"
+	"github.com/yuyudeqiu/chronicle/internal/service"
+)
+
+const githubIssues = `[
+{"number": 12, "title": "Login fails", "body": "Steps to reproduce", "state": "open",
+ "html_url": "https://github.com/acme/web/issues/12", "labels": [{"name": "bug"}, "p1"],
+ "milestone": {"due_on": "2026-03-20T00:00:00Z"},
+ "created_at": "2026-03-01T08:00:00Z", "updated_at": "2026-03-02T08:00:00Z",
+ "comments": [{"id": 501, "body": "Can reproduce", "user": {"login": "ann"}, "created_at": "2026-03-02T08:00:00Z"}, {"id": 502, "body": " "}]},
+{"number": 13, "title": "Bump deps", "state": "open", "html_url": "https://github.com/acme/web/pull/13", "pull_request": {}},
+{"id": 503, "issue_url": "https://api.github.com/repos/acme/web/issues/12", "body": "Fixed in #14", "user": {"login": "bob"}, "created_at": "2026-03-03T08:00:00Z"}
+]`
+
+func TestParseGitHubIssues(t *testing.T) {
+	items, errs, err := parseGitHubIssues([]byte(githubIssues))
+	if err != nil {
+		t.Fatalf("parseGitHubIssues: %v", err)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error, "pull request #13") {
+		t.Errorf("errors = %v, want the pull request skipped", errs)
+	}
+	if len(items) != 2 {
+		t.Fatalf("parsed %d items, want an issue and a comment", len(items))
+	}
+
+	issue := items[0]
+	if issue.task.ExternalRef != "github:acme/web#12" || issue.task.Category != "web" || issue.task.Status != model.TaskStatusTodo {
+		t.Errorf("issue = %s in %q (%s)", issue.task.ExternalRef, issue.task.Category, issue.task.Status)
+	}
+	if len(issue.task.Links) != 1 || issue.task.Links[0].Kind != model.LinkKindIssue || issue.task.Deadline == nil {
+		t.Errorf("issue links %v, deadline %v", issue.task.Links, issue.task.Deadline)
+	}
+	if strings.Join(issue.tags, ",") != "bug,p1" {
+		t.Errorf("labels = %v, want bug and p1", issue.tags)
+	}
+	if len(issue.logs) != 1 || issue.logs[0].LogText != "ann: Can reproduce" {
+		t.Errorf("comments = %+v, want the non-empty one", issue.logs)
+	}
+
+	comment := items[1]
+	if !comment.commentsOnly || comment.task.ExternalRef != "github:acme/web#12" || len(comment.logs) != 1 {
+		t.Errorf("separate comment = %+v, want a comment on #12", comment)
+	}
+}
+
+func TestParseGitLabIssues(t *testing.T) {
+	data := `[
+{"iid": 7, "title": "Flaky test", "state": "closed", "web_url": "https://gitlab.com/acme/tools/api/-/issues/7",
+ "labels": ["ci"], "due_date": "2026-03-15", "created_at": "2026-03-01T08:00:00Z", "updated_at": "2026-03-05T08:00:00Z", "closed_at": "2026-03-05T08:00:00Z",
+ "notes": [{"id": 9, "body": "changed the description", "system": true}, {"id": 10, "body": "Retried", "author": {"username": "ann"}, "created_at": "2026-03-04T08:00:00Z"}]},
+{"iid": 8, "title": "Unknown", "state": "merged", "references": {"full": "acme/tools/api#8"}},
+{"iid": 9, "title": "Bad date", "state": "opened", "references": {"full": "acme/tools/api#9"}, "due_date": "soon"}
+]`
+
+	items, errs, err := parseGitLabIssues([]byte(data), time.UTC)
+	if err != nil {
+		t.Fatalf("parseGitLabIssues: %v", err)
+	}
+	if len(errs) != 2 || len(items) != 1 {
+		t.Fatalf("parsed %d issues and %d errors (%v), want 1 and 2", len(items), len(errs), errs)
+	}
+
+	issue := items[0].task
+	if issue.ExternalRef != "gitlab:acme/tools/api#7" || issue.Category != "api" || issue.Status != model.TaskStatusDone || issue.ActualCompletedAt == nil {
+		t.Errorf("issue = %s in %q (%s) completed %v", issue.ExternalRef, issue.Category, issue.Status, issue.ActualCompletedAt)
+	}
+	if want := time.Date(2026, 3, 15, 23, 59, 0, 0, time.UTC); issue.Deadline == nil || !issue.Deadline.Equal(want) {
+		t.Errorf("deadline = %v, want %s", issue.Deadline, want)
+	}
+	if logs := items[0].logs; len(logs) != 1 || logs[0].LogText != "ann: Retried" {
+		t.Errorf("notes = %+v, want only the user note", logs)
+	}
+}
+
+func TestParseJiraIssues(t *testing.T) {
+	data := `{"issues": [
+{"key": "OPS-3", "self": "https://acme.atlassian.net/rest/api/3/issue/10003", "fields": {
+ "summary": "Rotate keys",
+ "description": {"type": "doc", "content": [{"type": "paragraph", "content": [{"type": "text", "text": "Before "}, {"type": "text", "text": "Friday"}]}]},
+ "status": {"name": "In Review", "statusCategory": {"key": "indeterminate"}},
+ "project": {"key": "OPS", "name": "Operations"}, "labels": ["security"], "priority": {"name": "High"},
+ "duedate": "2026-03-13", "created": "2026-03-01T09:00:00.000+0800", "updated": "2026-03-02T09:00:00.000+0800",
+ "comment": {"comments": [{"id": "1", "author": {"displayName": "Ann"}, "body": "Started", "created": "2026-03-02T09:00:00.000+0800"}]}}},
+{"key": "OPS-4", "fields": {"summary": "", "status": {"statusCategory": {"key": "new"}}}}
+]}`
+
+	items, errs, err := parseJiraIssues([]byte(data), time.UTC)
+	if err != nil {
+		t.Fatalf("parseJiraIssues: %v", err)
+	}
+	if len(errs) != 1 || len(items) != 1 {
+		t.Fatalf("parsed %d issues and %d errors (%v), want 1 and 1", len(items), len(errs), errs)
+	}
+
+	it := items[0]
+	if it.task.ExternalRef != "jira:OPS-3" || it.task.Category != "Operations" || it.task.Status != model.TaskStatusInProgress {
+		t.Errorf("issue = %s in %q (%s)", it.task.ExternalRef, it.task.Category, it.task.Status)
+	}
+	if it.task.Description != "Before Friday" {
+		t.Errorf("description = %q, want the text of the document", it.task.Description)
+	}
+	if len(it.task.Links) != 1 || it.task.Links[0].URL != "https://acme.atlassian.net/browse/OPS-3" {
+		t.Errorf("links = %v, want the browse page", it.task.Links)
+	}
+	if it.priority != "High" || !it.created.Equal(time.Date(2026, 3, 1, 1, 0, 0, 0, time.UTC)) {
+		t.Errorf("priority %q, created %s", it.priority, it.created)
+	}
+	if len(it.logs) != 1 || it.logs[0].LogText != "Ann: Started" {
+		t.Errorf("comments = %+v, want one worklog", it.logs)
+	}
+}
+
+func TestImportIssuesAgain(t *testing.T) {
+	setupDB(t)
+
+	importIssues := func(data string) *model.FileImportResult {
+		t.Helper()
+		result, err := ImportTaskList([]byte(data), model.ImportFormatGitHub, model.TaskListImportReq{}, time.UTC)
+		if err != nil {
+			t.Fatalf("ImportTaskList: %v", err)
+		}
+		return result
+	}
+
+	first := importIssues(githubIssues)
+	if first.Tasks.Created != 1 || first.Logs.Created != 2 {
+		t.Fatalf("first import = %+v, want the issue and both comments", first.ImportResult)
+	}
+
+	again := importIssues(githubIssues)
+	if again.Tasks.Created != 0 || again.Tasks.Skipped != 1 || again.Logs.Created != 0 {
+		t.Errorf("importing the same issues again = %+v, want nothing new", again.ImportResult)
+	}
+
+	closed := `[{"number": 12, "title": "Login fails", "state": "closed", "html_url": "https://github.com/acme/web/issues/12",
+ "created_at": "2026-03-01T08:00:00Z", "updated_at": "2026-03-04T08:00:00Z", "closed_at": "2026-03-04T08:00:00Z"}]`
+	update := importIssues(closed)
+	if update.Tasks.Updated != 1 || update.StatusChanges.Created != 1 {
+		t.Errorf("import of the closed issue = %+v, want the task updated with a status change", update.ImportResult)
+	}
+
+	tasks, err := service.FindTasksByRef("github:acme/web#12")
+	if err != nil {
+		t.Fatalf("FindTasksByRef: %v", err)
+	}
+	if len(tasks) != 1 {
+		t.Fatalf("found %d tasks for the issue, want 1", len(tasks))
+	}
+	task, err := service.GetTask(tasks[0].ID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if task.Status != model.TaskStatusDone || !strings.HasPrefix(task.Description, "Steps to reproduce") || len(task.Logs) != 2 {
+		t.Errorf("task = %s, description %q, %d worklogs; want done, the description kept and 2 worklogs", task.Status, task.Description, len(task.Logs))
+	}
+}
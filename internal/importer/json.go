@@ -0,0 +1,90 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// jsonRecord is one object of a JSON export and the line it starts on.
+type jsonRecord struct {
+	line int
+	raw  json.RawMessage
+}
+
+// readJSONRecords reads the objects of a JSON export: an array, one object per line
+// or, when key is given, the array under that key of a wrapping object (such as the
+// "issues" of a Jira search result).
+func readJSONRecords(data []byte, key string) ([]jsonRecord, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	lineAt := func() int {
+		off := int(dec.InputOffset())
+		rest := bytes.TrimLeft(data[off:], " \t\r\n,")
+		return bytes.Count(data[:len(data)-len(rest)], []byte("\n")) + 1
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	array := len(trimmed) > 0 && trimmed[0] == '['
+	if array {
+		if _, err := dec.Token(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+	} else if key != "" && len(trimmed) > 0 && trimmed[0] == '{' {
+		found, err := seekJSONArray(dec, key)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		if found {
+			array = true
+		} else {
+			// A single object, read again from the start
+			dec = json.NewDecoder(bytes.NewReader(data))
+		}
+	}
+
+	var records []jsonRecord
+	for {
+		if array && !dec.More() {
+			break
+		}
+		line := lineAt()
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidFile, line, err)
+		}
+		records = append(records, jsonRecord{line: line, raw: raw})
+	}
+	return records, nil
+}
+
+// seekJSONArray advances dec into the array under key of the object it starts with
+// and reports whether there is one.
+func seekJSONArray(dec *json.Decoder, key string) (bool, error) {
+	if _, err := dec.Token(); err != nil {
+		return false, err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return false, err
+		}
+		if tok == key {
+			tok, err := dec.Token()
+			if err != nil {
+				return false, err
+			}
+			if tok != json.Delim('[') {
+				return false, fmt.Errorf("%q is not an array", key)
+			}
+			return true, nil
+		}
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
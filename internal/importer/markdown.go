@@ -0,0 +1,135 @@
+package importer
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yuyudeqiu/chronicle/internal/model"
+)
+
+var (
+	mdHeadingPattern  = regexp.MustCompile(`^#{1,6}\s+(.*?)\s*#*\s*$`)
+	mdTaskPattern     = regexp.MustCompile(`^(\s*)[-*+]\s+\[([ xX/])\]\s+(.*)$`)
+	mdListItemPattern = regexp.MustCompile(`^\s*[-*+]\s+`)
+	// mdDatePattern matches the dates of the Obsidian Tasks plugin (📅 due, ✅ done,
+	// ➕ created) and due:YYYY-MM-DD.
+	mdDatePattern = regexp.MustCompile(`(📅|✅|➕|due:)\s*(\d{4}-\d{2}-\d{2})`)
+	mdTagPattern  = regexp.MustCompile(`(^|\s)#([\p{L}\p{N}_/-]+)`)
+)
+
+// mdPriorities maps the priority markers of the Obsidian Tasks plugin.
+var mdPriorities = map[string]string{"🔺": "最高", "⏫": "高", "🔼": "中", "🔽": "低", "⏬": "最低"}
+
+// parseMarkdownChecklists reads Markdown checklists: every "- [ ]" item is a task,
+// in the category of the heading above it; "- [x]" is done and "- [/]" in progress.
+// Checklist items nested under a task become its targets, other nested lines its
+// description.
+func parseMarkdownChecklists(data []byte, loc *time.Location) ([]listItem, []model.ImportRowError) {
+	var items []listItem
+	errs := []model.ImportRowError{}
+	category := ""
+	current := -1
+	indent := 0
+	inCode := false
+	for i, line := range strings.Split(strings.TrimPrefix(string(data), "\ufeff"), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			continue
+		}
+		if m := mdHeadingPattern.FindStringSubmatch(line); m != nil {
+			category = m[1]
+			current = -1
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		width := len(line) - len(strings.TrimLeft(line, " \t"))
+		nested := current >= 0 && width > indent
+		if m := mdTaskPattern.FindStringSubmatch(line); m != nil {
+			if nested {
+				t := &items[current].task
+				mark := " "
+				if m[2] != " " {
+					mark = "x"
+				}
+				t.Targets += "- [" + mark + "] " + strings.TrimSpace(m[3]) + "\n"
+				continue
+			}
+			it := parseMarkdownTask(m[2], m[3], loc)
+			if it.task.Title == "" {
+				errs = append(errs, model.ImportRowError{Line: i + 1, Error: "task has no description"})
+				current = -1
+				continue
+			}
+			it.line = i + 1
+			it.task.Category = category
+			items = append(items, it)
+			current, indent = len(items)-1, width
+			continue
+		}
+		if nested {
+			t := &items[current].task
+			t.Description += mdListItemPattern.ReplaceAllString(strings.TrimSpace(line), "") + "\n"
+			continue
+		}
+		current = -1
+	}
+
+	for i := range items {
+		t := &items[i].task
+		t.Targets = strings.TrimRight(t.Targets, "\n")
+		t.Description = strings.TrimRight(t.Description, "\n")
+	}
+	return items, errs
+}
+
+func parseMarkdownTask(mark, text string, loc *time.Location) listItem {
+	it := listItem{task: model.Task{Status: model.TaskStatusTodo}}
+	switch mark {
+	case "x", "X":
+		it.task.Status = model.TaskStatusDone
+	case "/":
+		it.task.Status = model.TaskStatusInProgress
+	}
+
+	for _, m := range mdDatePattern.FindAllStringSubmatch(text, -1) {
+		day, ok := parseListDate(m[2], loc)
+		if !ok {
+			continue
+		}
+		switch m[1] {
+		case "📅", "due:":
+			it.task.Deadline = endOfDay(day)
+		case "✅":
+			it.task.ActualCompletedAt = &day
+			it.stamp = day
+		case "➕":
+			it.created = day
+		}
+	}
+	text = mdDatePattern.ReplaceAllString(text, "")
+
+	for marker, priority := range mdPriorities {
+		if strings.Contains(text, marker) {
+			it.priority = priority
+			text = strings.ReplaceAll(text, marker, "")
+		}
+	}
+	for _, m := range mdTagPattern.FindAllStringSubmatch(text, -1) {
+		it.tags = append(it.tags, "#"+m[2])
+	}
+	text = mdTagPattern.ReplaceAllString(text, "$1")
+
+	if it.stamp.IsZero() {
+		it.stamp = it.created
+	}
+	it.task.Title = strings.Join(strings.Fields(text), " ")
+	return it
+}
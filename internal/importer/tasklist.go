@@ -0,0 +1,292 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yuyudeqiu/chronicle/internal/model"
+	"github.com/yuyudeqiu/chronicle/internal/service"
+)
+
+// ErrUnknownFormat is returned by ImportTaskList for a format it cannot read.
+var ErrUnknownFormat = errors.New("unknown import format")
+
+// DefaultCategory is given to imported tasks without a project or heading when no
+// other category is configured.
+const DefaultCategory = "inbox"
+
+// TaskListFormats lists the task list formats ImportTaskList reads.
+var TaskListFormats = []string{
+	model.ImportFormatTodoTxt, model.ImportFormatTaskwarrior, model.ImportFormatMarkdown,
+	model.ImportFormatGitHub, model.ImportFormatGitLab, model.ImportFormatJira,
+}
+
+// listItem is a task read from a task list file, with the worklogs read with it.
+type listItem struct {
+	line int
+	task model.Task
+	logs []model.TaskLog
+	// created and stamp are the creation time and the newest time (completion or
+	// modification) recorded for the task in the file; zero if there is none.
+	created  time.Time
+	stamp    time.Time
+	priority string
+	tags     []string
+	// commentsOnly marks comments given apart from their issue, which is named by
+	// task.ExternalRef.
+	commentsOnly bool
+}
+
+// ImportTaskList imports a todo.txt file, a Taskwarrior export, Markdown checklists
+// or issues exported from GitHub, GitLab or Jira. Dates without a time are read in
+// loc.
+//
+// Tasks without an id in the file get one derived from their category and title;
+// issues are found by their external reference (such as "github:owner/repo#12"). So
+// importing the same file again finds the tasks imported before: they are only
+// updated when the file records a newer change (a completion, or the modification
+// time of a Taskwarrior task or an issue) than the task's last update, and keep what
+// the file does not provide, such as targets and links. Chronicle has no priorities
+// or labels, so these are kept in the description. Issue comments become worklogs.
+func ImportTaskList(data []byte, format string, req model.TaskListImportReq, loc *time.Location) (*model.FileImportResult, error) {
+	var items []listItem
+	result := &model.FileImportResult{Errors: []model.ImportRowError{}, Items: []model.ImportedTask{}, DryRun: req.DryRun}
+	switch format {
+	case model.ImportFormatTodoTxt:
+		items, result.Errors = parseTodoTxt(data, loc)
+	case model.ImportFormatTaskwarrior:
+		var err error
+		items, result.Errors, err = parseTaskwarrior(data)
+		if err != nil {
+			return nil, err
+		}
+	case model.ImportFormatMarkdown:
+		items, result.Errors = parseMarkdownChecklists(data, loc)
+	case model.ImportFormatGitHub, model.ImportFormatGitLab, model.ImportFormatJira:
+		var err error
+		switch format {
+		case model.ImportFormatGitHub:
+			items, result.Errors, err = parseGitHubIssues(data)
+		case model.ImportFormatGitLab:
+			items, result.Errors, err = parseGitLabIssues(data, loc)
+		default:
+			items, result.Errors, err = parseJiraIssues(data, loc)
+		}
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: %q (supported: %s)", ErrUnknownFormat, format, strings.Join(TaskListFormats, ", "))
+	}
+
+	category := req.Category
+	if category == "" {
+		category = DefaultCategory
+	}
+	var refs []string
+	for _, it := range items {
+		if it.task.ExternalRef != "" {
+			refs = append(refs, it.task.ExternalRef)
+		}
+	}
+	byRef, err := service.GetTasksByExternalRef(refs)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	seen := make(map[string]int)
+	var kept, comments []listItem
+	for _, it := range items {
+		t := &it.task
+		if it.commentsOnly {
+			comments = append(comments, it)
+			continue
+		}
+		if t.Category == "" {
+			t.Category = category
+		}
+		if t.ExternalRef != "" {
+			t.ID = uuid.NewSHA1(uuid.NameSpaceURL, []byte("chronicle:"+t.ExternalRef)).String()
+			if old, ok := byRef[t.ExternalRef]; ok {
+				t.ID = old.ID
+			}
+		}
+		if t.ID == "" {
+			t.ID = listTaskID(format, t.Category, t.Title)
+		}
+		if first, ok := seen[t.ID]; ok {
+			what := fmt.Sprintf("task %q in %s", t.Title, t.Category)
+			if t.ExternalRef != "" {
+				what = "issue " + t.ExternalRef
+			}
+			result.Errors = append(result.Errors, model.ImportRowError{Line: it.line, Error: fmt.Sprintf("duplicate %s (first on line %d)", what, first)})
+			continue
+		}
+		seen[t.ID] = it.line
+
+		var extra []string
+		if it.priority != "" {
+			extra = append(extra, "优先级: "+it.priority)
+		}
+		if len(it.tags) > 0 {
+			extra = append(extra, "标签: "+strings.Join(it.tags, ", "))
+		}
+		if len(extra) > 0 {
+			if t.Description != "" {
+				t.Description += "\n\n"
+			}
+			t.Description += strings.Join(extra, "\n")
+		}
+		kept = append(kept, it)
+	}
+
+	dump := &model.Dump{Format: model.DumpFormat, Version: model.DumpVersion}
+	logIDs := make(map[string]bool)
+	addLog := func(l model.TaskLog, taskID string) {
+		// The same comment may be given both with and apart from its issue
+		if !logIDs[l.ID] {
+			logIDs[l.ID] = true
+			l.TaskID = taskID
+			dump.Logs = append(dump.Logs, l)
+		}
+	}
+	// Comments given apart from their issue go to the issue in the file, or else to
+	// the task it was imported as before
+	for _, c := range comments {
+		ref := c.task.ExternalRef
+		found := false
+		for i := range kept {
+			if kept[i].task.ExternalRef == ref {
+				kept[i].logs = append(kept[i].logs, c.logs...)
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		old, ok := byRef[ref]
+		if !ok {
+			result.Errors = append(result.Errors, model.ImportRowError{Line: c.line, Error: fmt.Sprintf("comment on issue %s, which is neither in the file nor imported", ref)})
+			continue
+		}
+		for _, l := range c.logs {
+			addLog(l, old.ID)
+		}
+	}
+
+	var ids []string
+	for _, it := range kept {
+		ids = append(ids, it.task.ID)
+	}
+	existing, err := service.GetTasksByID(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, it := range kept {
+		t := it.task
+		old, exists := existing[t.ID]
+
+		t.CreatedAt = it.created
+		if t.CreatedAt.IsZero() {
+			t.CreatedAt = now
+			if exists {
+				t.CreatedAt = old.CreatedAt
+			}
+		}
+		switch {
+		case !it.stamp.IsZero():
+			t.UpdatedAt = it.stamp
+		case exists:
+			t.UpdatedAt = old.UpdatedAt
+		default:
+			t.UpdatedAt = t.CreatedAt
+		}
+		// A completion in the file is carried over even without a newer date
+		if exists && t.Status == model.TaskStatusDone && old.Status != model.TaskStatusDone && !t.UpdatedAt.After(old.UpdatedAt) {
+			t.UpdatedAt = now
+		}
+		if t.Status == model.TaskStatusDone && t.ActualCompletedAt == nil {
+			completed := t.UpdatedAt
+			t.ActualCompletedAt = &completed
+		}
+
+		action := model.ImportActionCreate
+		if exists {
+			action = model.ImportActionSkip
+			if t.UpdatedAt.After(old.UpdatedAt) {
+				action = model.ImportActionUpdate
+			}
+			t = mergeListTask(old, t)
+		}
+		result.Items = append(result.Items, model.ImportedTask{Line: it.line, Action: action, Task: t, Logs: len(it.logs)})
+		dump.Tasks = append(dump.Tasks, t)
+		for _, l := range it.logs {
+			addLog(l, t.ID)
+		}
+	}
+
+	imported, err := importRows(dump, result, req.DryRun)
+	if err != nil {
+		return nil, err
+	}
+	result.ImportResult = *imported
+	sort.SliceStable(result.Errors, func(i, j int) bool { return result.Errors[i].Line < result.Errors[j].Line })
+	return result, nil
+}
+
+// listTaskID derives the id of a task from a file without ids.
+func listTaskID(format, category, title string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte("chronicle:"+format+":"+category+"\x00"+title)).String()
+}
+
+// mergeListTask applies what a task list file says about a task to the existing
+// task, keeping the fields the file leaves empty.
+func mergeListTask(old, t model.Task) model.Task {
+	merged := old
+	merged.Logs = nil
+	merged.Title = t.Title
+	merged.Category = t.Category
+	merged.Status = t.Status
+	merged.ActualCompletedAt = t.ActualCompletedAt
+	merged.UpdatedAt = t.UpdatedAt
+	if t.Status != model.TaskStatusDone {
+		merged.ActualCompletedAt = nil
+	}
+	if t.Deadline != nil {
+		merged.Deadline = t.Deadline
+	}
+	if t.Description != "" {
+		merged.Description = t.Description
+	}
+	if t.Targets != "" {
+		merged.Targets = t.Targets
+	}
+	for _, l := range t.Links {
+		if !merged.Links.Has(l.URL) {
+			merged.Links = append(merged.Links, l)
+		}
+	}
+	if t.ExternalRef != "" {
+		merged.ExternalRef = t.ExternalRef
+	}
+	return merged
+}
+
+// parseListDate parses a YYYY-MM-DD date in loc.
+func parseListDate(s string, loc *time.Location) (time.Time, bool) {
+	t, err := time.ParseInLocation("2006-01-02", s, loc)
+	return t, err == nil
+}
+
+// endOfDay is the deadline given by a due date without a time.
+func endOfDay(day time.Time) *time.Time {
+	t := time.Date(day.Year(), day.Month(), day.Day(), 23, 59, 0, 0, day.Location())
+	return &t
+}
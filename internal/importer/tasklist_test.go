@@ -0,0 +1,175 @@
+package importer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yuyudeqiu/chronicle/internal/model"
+	"github.com/yuyudeqiu/chronicle/internal/service"
+)
+
+func TestParseTodoTxt(t *testing.T) {
+	data := "(A) 2026-03-01 Write report +work +q1 @office due:2026-03-10\n" +
+		"\n" +
+		"x 2026-03-05 2026-03-01 Pay bills +home pri:B\n" +
+		"x\n" +
+		"Fix bike due:2026-13-01\n"
+
+	items, errs := parseTodoTxt([]byte(data), time.UTC)
+	if want := []model.ImportRowError{{Line: 4, Error: "task has no description"}, {Line: 5, Error: `invalid due date "2026-13-01" (expected YYYY-MM-DD)`}}; !reflect.DeepEqual(errs, want) {
+		t.Errorf("errors = %v, want %v", errs, want)
+	}
+	if len(items) != 2 {
+		t.Fatalf("parsed %d tasks, want 2", len(items))
+	}
+
+	open := items[0]
+	if open.line != 1 || open.task.Title != "Write report" || open.task.Category != "work" || open.task.Status != model.TaskStatusTodo {
+		t.Errorf("open task = line %d %q in %q (%s)", open.line, open.task.Title, open.task.Category, open.task.Status)
+	}
+	if open.priority != "A" || !reflect.DeepEqual(open.tags, []string{"+q1", "@office"}) {
+		t.Errorf("open task priority %q, tags %v; want A, [+q1 @office]", open.priority, open.tags)
+	}
+	if want := time.Date(2026, 3, 10, 23, 59, 0, 0, time.UTC); open.task.Deadline == nil || !open.task.Deadline.Equal(want) {
+		t.Errorf("deadline = %v, want %s", open.task.Deadline, want)
+	}
+
+	done := items[1]
+	completed := time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)
+	if done.task.Status != model.TaskStatusDone || done.task.ActualCompletedAt == nil || !done.task.ActualCompletedAt.Equal(completed) || !done.stamp.Equal(completed) {
+		t.Errorf("done task = %s completed %v stamp %s, want done on %s", done.task.Status, done.task.ActualCompletedAt, done.stamp, completed)
+	}
+	if !done.created.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) || done.priority != "B" {
+		t.Errorf("done task created %s priority %q, want 2026-03-01 and B", done.created, done.priority)
+	}
+}
+
+func TestParseTaskwarrior(t *testing.T) {
+	data := `[
+{"uuid":"5f4e3d2c-0000-4000-8000-000000000001","description":"Review PR","status":"pending","project":"work","tags":["code"],"entry":"20260301T080000Z","start":"20260302T090000Z","annotations":[{"entry":"20260302T100000Z","description":"left comments"}]},
+{"uuid":"5f4e3d2c-0000-4000-8000-000000000002","description":"Renew passport","status":"completed","entry":"20260301T080000Z","end":"20260304T120000Z","modified":"20260304T120001Z"},
+{"uuid":"5f4e3d2c-0000-4000-8000-000000000003","description":"Old idea","status":"deleted"},
+{"uuid":"not-a-uuid","description":"Broken","status":"pending"}
+]`
+
+	items, errs, err := parseTaskwarrior([]byte(data))
+	if err != nil {
+		t.Fatalf("parseTaskwarrior: %v", err)
+	}
+	if len(errs) != 2 || len(items) != 2 {
+		t.Fatalf("parsed %d tasks and %d errors (%v), want 2 and 2", len(items), len(errs), errs)
+	}
+
+	started := items[0]
+	if started.task.ID != "5f4e3d2c-0000-4000-8000-000000000001" || started.task.Status != model.TaskStatusInProgress || started.task.Category != "work" {
+		t.Errorf("started task = %s %s in %q", started.task.ID, started.task.Status, started.task.Category)
+	}
+	if len(started.logs) != 1 || started.logs[0].LogText != "left comments" || started.logs[0].TaskID != started.task.ID {
+		t.Errorf("annotations = %+v, want one worklog", started.logs)
+	}
+
+	completed := items[1]
+	if completed.task.Status != model.TaskStatusDone || completed.task.ActualCompletedAt == nil {
+		t.Errorf("completed task = %s completed %v", completed.task.Status, completed.task.ActualCompletedAt)
+	}
+	if want := time.Date(2026, 3, 4, 12, 0, 1, 0, time.UTC); !completed.stamp.Equal(want) {
+		t.Errorf("stamp = %s, want the modification time %s", completed.stamp, want)
+	}
+
+	if _, _, err := parseTaskwarrior([]byte(`[{"uuid": 1}]`)); err == nil {
+		t.Error("parseTaskwarrior accepted a malformed export")
+	}
+}
+
+func TestParseMarkdownChecklists(t *testing.T) {
+	data := "# Work\n" +
+		"- [ ] Ship release ⏫ #release 📅 2026-03-20\n" +
+		"  - [x] tag version\n" +
+		"  - [ ] write notes\n" +
+		"  needs sign-off\n" +
+		"- [/] Migrate CI\n" +
+		"```\n" +
+		"- [ ] not a task\n" +
+		"```\n" +
+		"## Home\n" +
+		"- [x] Water plants ✅ 2026-03-02\n" +
+		"- [ ] \n"
+
+	items, errs := parseMarkdownChecklists([]byte(data), time.UTC)
+	if want := []model.ImportRowError{{Line: 12, Error: "task has no description"}}; !reflect.DeepEqual(errs, want) {
+		t.Errorf("errors = %v, want %v", errs, want)
+	}
+	if len(items) != 3 {
+		t.Fatalf("parsed %d tasks, want 3", len(items))
+	}
+
+	release := items[0].task
+	if release.Title != "Ship release" || release.Category != "Work" || release.Status != model.TaskStatusTodo {
+		t.Errorf("release = %q in %q (%s)", release.Title, release.Category, release.Status)
+	}
+	if release.Targets != "- [x] tag version\n- [ ] write notes" || release.Description != "needs sign-off" {
+		t.Errorf("release targets %q, description %q", release.Targets, release.Description)
+	}
+	if items[0].priority != "高" || !reflect.DeepEqual(items[0].tags, []string{"#release"}) || release.Deadline == nil {
+		t.Errorf("release priority %q, tags %v, deadline %v", items[0].priority, items[0].tags, release.Deadline)
+	}
+
+	if ci := items[1].task; ci.Title != "Migrate CI" || ci.Status != model.TaskStatusInProgress {
+		t.Errorf("ci = %q (%s), want Migrate CI in progress", ci.Title, ci.Status)
+	}
+	if plants := items[2].task; plants.Category != "Home" || plants.Status != model.TaskStatusDone || plants.ActualCompletedAt == nil {
+		t.Errorf("plants = %q in %q (%s) completed %v", plants.Title, plants.Category, plants.Status, plants.ActualCompletedAt)
+	}
+}
+
+func TestImportTaskListAgain(t *testing.T) {
+	setupDB(t)
+
+	first, err := ImportTaskList([]byte("Write report +work\n"), model.ImportFormatTodoTxt, model.TaskListImportReq{}, time.UTC)
+	if err != nil {
+		t.Fatalf("first import: %v", err)
+	}
+	if first.Tasks.Created != 1 {
+		t.Fatalf("first import = %+v, want one task created", first.ImportResult)
+	}
+	id := first.Items[0].Task.ID
+
+	again, err := ImportTaskList([]byte("Write report +work\n"), model.ImportFormatTodoTxt, model.TaskListImportReq{}, time.UTC)
+	if err != nil {
+		t.Fatalf("second import: %v", err)
+	}
+	if again.Tasks.Created != 0 || again.Tasks.Skipped != 1 || again.Items[0].Action != model.ImportActionSkip {
+		t.Errorf("importing the same file again = %+v, want the task skipped", again.ImportResult)
+	}
+
+	done, err := ImportTaskList([]byte("x 2026-03-05 Write report +work\n"), model.ImportFormatTodoTxt, model.TaskListImportReq{}, time.UTC)
+	if err != nil {
+		t.Fatalf("import of the completion: %v", err)
+	}
+	if done.Tasks.Updated != 1 || done.StatusChanges.Created != 1 {
+		t.Errorf("import of the completion = %+v, want the task updated with a status change", done.ImportResult)
+	}
+
+	task, err := service.GetTask(id)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if task.Status != model.TaskStatusDone || task.ActualCompletedAt == nil {
+		t.Errorf("task = %s completed %v, want done", task.Status, task.ActualCompletedAt)
+	}
+	var changes []model.TaskStatusChange
+	if err := service.DB.Where("task_id = ?", id).Order("created_at asc").Find(&changes).Error; err != nil {
+		t.Fatal(err)
+	}
+	if n := len(changes); n == 0 || changes[n-1].FromStatus != model.TaskStatusTodo || changes[n-1].ToStatus != model.TaskStatusDone {
+		t.Errorf("status changes = %+v, want the move to done recorded", changes)
+	}
+	var count int64
+	if err := service.DB.Model(&model.Task{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("%d tasks after three imports, want 1", count)
+	}
+}
@@ -0,0 +1,132 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yuyudeqiu/chronicle/internal/model"
+)
+
+// taskwarriorTimeLayout is the UTC timestamp format of "task export".
+const taskwarriorTimeLayout = "20060102T150405Z"
+
+// twTask is a task as written by "task export".
+type twTask struct {
+	UUID        string   `json:"uuid"`
+	Description string   `json:"description"`
+	Status      string   `json:"status"`
+	Project     string   `json:"project"`
+	Tags        []string `json:"tags"`
+	Priority    string   `json:"priority"`
+	Entry       string   `json:"entry"`
+	Modified    string   `json:"modified"`
+	Start       string   `json:"start"`
+	End         string   `json:"end"`
+	Due         string   `json:"due"`
+	Annotations []struct {
+		Entry       string `json:"entry"`
+		Description string `json:"description"`
+	} `json:"annotations"`
+}
+
+// parseTaskwarrior reads the output of "task export", a JSON array or one task per
+// line. Tasks keep their UUID, projects become categories, annotations worklogs
+// and started tasks are in progress. Deleted tasks and recurrence templates are
+// skipped.
+func parseTaskwarrior(data []byte) ([]listItem, []model.ImportRowError, error) {
+	records, err := readJSONRecords(data, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var items []listItem
+	errs := []model.ImportRowError{}
+	for _, r := range records {
+		var tw twTask
+		if err := json.Unmarshal(r.raw, &tw); err != nil {
+			return nil, nil, fmt.Errorf("%w: line %d: %v", ErrInvalidFile, r.line, err)
+		}
+		it, err := parseTaskwarriorTask(tw)
+		if err != nil {
+			errs = append(errs, model.ImportRowError{Line: r.line, Error: err.Error()})
+			continue
+		}
+		it.line = r.line
+		items = append(items, it)
+	}
+	return items, errs, nil
+}
+
+func parseTaskwarriorTask(tw twTask) (listItem, error) {
+	var it listItem
+	if _, err := uuid.Parse(tw.UUID); err != nil {
+		return it, fmt.Errorf("invalid uuid %q", tw.UUID)
+	}
+	if tw.Description == "" {
+		return it, errors.New("task has no description")
+	}
+
+	times := make(map[string]*time.Time)
+	for name, s := range map[string]string{"entry": tw.Entry, "modified": tw.Modified, "start": tw.Start, "end": tw.End, "due": tw.Due} {
+		if s == "" {
+			continue
+		}
+		t, err := time.Parse(taskwarriorTimeLayout, s)
+		if err != nil {
+			return it, fmt.Errorf("invalid %s %q", name, s)
+		}
+		times[name] = &t
+	}
+
+	it.task = model.Task{
+		ID:       tw.UUID,
+		Title:    tw.Description,
+		Category: tw.Project,
+		Deadline: times["due"],
+	}
+	switch tw.Status {
+	case "pending", "waiting":
+		it.task.Status = model.TaskStatusTodo
+		if times["start"] != nil {
+			it.task.Status = model.TaskStatusInProgress
+		}
+	case "completed":
+		it.task.Status = model.TaskStatusDone
+		it.task.ActualCompletedAt = times["end"]
+	case "deleted":
+		return it, errors.New("deleted task skipped")
+	case "recurring":
+		return it, errors.New("recurrence template skipped (its instances are imported)")
+	default:
+		return it, fmt.Errorf("invalid status %q", tw.Status)
+	}
+
+	if t := times["entry"]; t != nil {
+		it.created = *t
+	}
+	for _, name := range []string{"entry", "end", "modified"} {
+		if t := times[name]; t != nil && t.After(it.stamp) {
+			it.stamp = *t
+		}
+	}
+	it.priority = tw.Priority
+	it.tags = tw.Tags
+
+	for _, a := range tw.Annotations {
+		at, err := time.Parse(taskwarriorTimeLayout, a.Entry)
+		if err != nil || a.Description == "" {
+			return it, fmt.Errorf("invalid annotation %q", a.Description)
+		}
+		it.logs = append(it.logs, model.TaskLog{
+			// Derived from the annotation, so importing it again finds it
+			ID:        uuid.NewSHA1(uuid.NameSpaceURL, []byte("chronicle:taskwarrior:"+tw.UUID+":"+a.Entry+":"+a.Description)).String(),
+			TaskID:    tw.UUID,
+			LogText:   a.Description,
+			CreatedAt: at,
+		})
+	}
+	return it, nil
+}
@@ -0,0 +1,115 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yuyudeqiu/chronicle/internal/model"
+)
+
+var (
+	// todoPriorityPattern matches the priority that starts an open todo.txt task.
+	todoPriorityPattern = regexp.MustCompile(`^\(([A-Z])\)$`)
+	// todoTagPattern matches key:value extensions such as due:2026-03-01.
+	todoTagPattern = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9_-]*):([^\s:/][^\s]*)$`)
+)
+
+// parseTodoTxt reads a todo.txt file (https://github.com/todotxt/todo.txt): "x"
+// marks a task done, the first +project becomes its category and @contexts and
+// further projects its tags; due:YYYY-MM-DD sets the deadline.
+func parseTodoTxt(data []byte, loc *time.Location) ([]listItem, []model.ImportRowError) {
+	var items []listItem
+	errs := []model.ImportRowError{}
+	for i, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(strings.TrimPrefix(line, "\ufeff"))
+		if len(fields) == 0 {
+			continue
+		}
+		it, err := parseTodoLine(fields, loc)
+		if err != nil {
+			errs = append(errs, model.ImportRowError{Line: i + 1, Error: err.Error()})
+			continue
+		}
+		it.line = i + 1
+		items = append(items, it)
+	}
+	return items, errs
+}
+
+func parseTodoLine(fields []string, loc *time.Location) (listItem, error) {
+	it := listItem{task: model.Task{Status: model.TaskStatusTodo}}
+	date := func() (time.Time, bool) {
+		if len(fields) == 0 {
+			return time.Time{}, false
+		}
+		d, ok := parseListDate(fields[0], loc)
+		if ok {
+			fields = fields[1:]
+		}
+		return d, ok
+	}
+
+	if fields[0] == "x" {
+		fields = fields[1:]
+		it.task.Status = model.TaskStatusDone
+		// Completion date, then creation date
+		if completed, ok := date(); ok {
+			it.task.ActualCompletedAt = &completed
+			it.stamp = completed
+			it.created, _ = date()
+		}
+	} else {
+		if len(fields) > 0 {
+			if m := todoPriorityPattern.FindStringSubmatch(fields[0]); m != nil {
+				it.priority = m[1]
+				fields = fields[1:]
+			}
+		}
+		it.created, _ = date()
+	}
+	if it.stamp.IsZero() {
+		it.stamp = it.created
+	}
+
+	var words []string
+	for _, f := range fields {
+		switch {
+		case len(f) > 1 && f[0] == '+':
+			if it.task.Category == "" {
+				it.task.Category = f[1:]
+			} else {
+				it.tags = append(it.tags, f)
+			}
+		case len(f) > 1 && f[0] == '@':
+			it.tags = append(it.tags, f)
+		default:
+			m := todoTagPattern.FindStringSubmatch(f)
+			if m == nil {
+				words = append(words, f)
+				continue
+			}
+			switch m[1] {
+			case "due":
+				due, ok := parseListDate(m[2], loc)
+				if !ok {
+					return it, fmt.Errorf("invalid due date %q (expected YYYY-MM-DD)", m[2])
+				}
+				it.task.Deadline = endOfDay(due)
+			case "pri":
+				// Priority kept on completed tasks by some clients
+				it.priority = m[2]
+			default:
+				words = append(words, f)
+			}
+		}
+	}
+
+	it.task.Title = strings.Join(words, " ")
+	if it.task.Title == "" {
+		return it, errors.New("task has no description")
+	}
+	return it, nil
+}
@@ -0,0 +1,14 @@
+package model
+
+const (
+	CalendarTypeEvent = "event"
+	CalendarTypeTodo  = "todo"
+)
+
+// CalendarReq selects the contents of the iCalendar feed of task deadlines.
+type CalendarReq struct {
+	// Type is CalendarTypeEvent (default, VEVENT, shown by calendar apps) or
+	// CalendarTypeTodo (VTODO, shown by task apps such as Apple Reminders).
+	Type     string `form:"type" json:"type"`
+	Category string `form:"category" json:"category"`
+}
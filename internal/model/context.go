@@ -0,0 +1,93 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Sections of the agent context, in priority order.
+const (
+	ContextSectionOverdue    = "overdue"
+	ContextSectionInProgress = "in_progress"
+	ContextSectionDueSoon    = "due_soon"
+	ContextSectionToday      = "today"
+)
+
+// Budgets of the agent context, in estimated tokens.
+const (
+	DefaultContextBudget = 1000
+	MinContextBudget     = 100
+)
+
+var contextSectionTitles = map[string]string{
+	ContextSectionOverdue:    "Overdue",
+	ContextSectionInProgress: "In progress",
+	ContextSectionDueSoon:    "Due in 7 days",
+	ContextSectionToday:      "Today",
+}
+
+// AgentContextResp is a compact snapshot of the work at hand, trimmed to a token
+// budget: overdue tasks first, then tasks in progress with their last worklog,
+// tasks due soon and today's worklogs.
+type AgentContextResp struct {
+	Date       string `json:"date"`
+	InProgress int    `json:"in_progress"`
+	Todo       int    `json:"todo"`
+	Budget     int    `json:"budget"`
+	// Tokens estimates the size of the snapshot written as text (Text), which is
+	// what the budget applies to; this JSON form is larger.
+	Tokens    int                   `json:"tokens"`
+	Truncated bool                  `json:"truncated"`
+	Sections  []AgentContextSection `json:"sections"`
+}
+
+// AgentContextSection lists the first items of a section that fit the budget; Total
+// counts all of them.
+type AgentContextSection struct {
+	Name    string   `json:"name"`
+	Total   int      `json:"total"`
+	Items   []string `json:"items"`
+	Omitted int      `json:"omitted,omitempty"`
+}
+
+// Header is the first line of the snapshot.
+func (r *AgentContextResp) Header() string {
+	return fmt.Sprintf("Chronicle %s: %d in progress, %d todo", r.Date, r.InProgress, r.Todo)
+}
+
+// Title is the heading line of the section.
+func (s AgentContextSection) Title() string {
+	return fmt.Sprintf("## %s (%d)", contextSectionTitles[s.Name], s.Total)
+}
+
+// Marker is the line standing in for omitted items.
+func (s AgentContextSection) Marker() string {
+	return fmt.Sprintf("- … %d more", s.Omitted)
+}
+
+// Footer is the last line of a truncated snapshot.
+func (r *AgentContextResp) Footer() string {
+	omitted := 0
+	for _, s := range r.Sections {
+		omitted += s.Omitted
+	}
+	return fmt.Sprintf("[truncated to ~%d tokens: %d items omitted]", r.Budget, omitted)
+}
+
+// Text writes the snapshot as plain text, one item per line.
+func (r *AgentContextResp) Text() string {
+	lines := []string{r.Header()}
+	for _, s := range r.Sections {
+		lines = append(lines, s.Title())
+		for _, item := range s.Items {
+			lines = append(lines, "- "+item)
+		}
+		if s.Omitted > 0 {
+			lines = append(lines, s.Marker())
+		}
+	}
+	if r.Truncated {
+		lines = append(lines, r.Footer())
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,39 @@
+package model
+
+const (
+	CSVTypeTasks    = "tasks"
+	CSVTypeWorklogs = "worklogs"
+)
+
+// CSVExportReq selects the contents of a CSV export. Tasks are filtered like the
+// list API; worklogs by date range.
+type CSVExportReq struct {
+	// Type is CSVTypeTasks (default) or CSVTypeWorklogs.
+	Type string `form:"type" json:"type"`
+	// Status is a comma separated list such as "in-progress,todo"; empty means all.
+	Status   string `form:"status" json:"status"`
+	Category string `form:"category" json:"category"`
+	// Archived also includes archived tasks.
+	Archived bool `form:"archived" json:"archived"`
+	// From / To bound worklogs (YYYY-MM-DD, inclusive), defaulting to the last 30 days.
+	From string `form:"from" json:"from"`
+	To   string `form:"to" json:"to"`
+}
+
+// ImportRowError reports a row of an imported file that was not imported.
+type ImportRowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// FileImportResult is the outcome of importing a file row by row: valid rows are
+// imported, the others are listed in Errors.
+type FileImportResult struct {
+	ImportResult
+	Errors []ImportRowError `json:"errors"`
+	// DryRun is set when nothing was written; the counts are what an import would do.
+	DryRun bool `json:"dry_run,omitempty"`
+	// Items lists the tasks read from a task list file (todo.txt, Taskwarrior or
+	// Markdown) and what the import did with each.
+	Items []ImportedTask `json:"items,omitempty"`
+}
@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+const (
+	// DumpFormat identifies a chronicle JSON dump.
+	DumpFormat = "chronicle-dump"
+	// DumpVersion is the layout version written by this binary. It is bumped on
+	// incompatible changes; imports of unknown versions are rejected. Version 2
+	// writes task links as an array of objects instead of one URL per line.
+	DumpVersion = 2
+)
+
+const (
+	// ImportModeMerge keeps existing data, adding new records and replacing tasks
+	// whose imported copy was updated more recently.
+	ImportModeMerge = "merge"
+	// ImportModeReplace deletes all existing data before importing.
+	ImportModeReplace = "replace"
+)
+
+// Dump is a complete, lossless export of the database. Records keep their IDs and
+// timestamps, so a dump can be imported into another instance unchanged.
+type Dump struct {
+	// Format is always DumpFormat.
+	Format string `json:"format"`
+	// Version is the dump layout version, see DumpVersion.
+	Version int `json:"version"`
+	// SchemaVersion is the database schema version of the exporting instance.
+	SchemaVersion int       `json:"schema_version"`
+	ExportedAt    time.Time `json:"exported_at"`
+	// Tasks holds all tasks, archived ones included (archived_at set), without logs.
+	Tasks []Task `json:"tasks"`
+	// Logs holds all worklogs; task_id refers to a task in Tasks.
+	Logs []TaskLog `json:"logs"`
+	// StatusChanges holds the status history used by flow metrics and charts.
+	StatusChanges []TaskStatusChange `json:"status_changes"`
+}
+
+// ImportCounts counts what an import did with one kind of record.
+type ImportCounts struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Skipped int `json:"skipped"`
+}
+
+type ImportResult struct {
+	Mode          string       `json:"mode"`
+	Tasks         ImportCounts `json:"tasks"`
+	Logs          ImportCounts `json:"logs"`
+	StatusChanges ImportCounts `json:"status_changes"`
+}
@@ -0,0 +1,34 @@
+package model
+
+const (
+	// ExportOutputFile renders all selected tasks into one document.
+	ExportOutputFile = "file"
+	// ExportOutputZip renders one file per task and packs them into a zip archive.
+	ExportOutputZip = "zip"
+)
+
+// ExportReq selects the tasks of an export and how they are packaged. Filters
+// combine; without any, every task is exported, archived ones included.
+type ExportReq struct {
+	// Date selects tasks completed on that day (YYYY-MM-DD).
+	Date string `form:"date" json:"date"`
+	// From / To select tasks completed in a date range (YYYY-MM-DD, inclusive);
+	// either bound may be left open.
+	From     string `form:"from" json:"from"`
+	To       string `form:"to" json:"to"`
+	Category string `form:"category" json:"category"`
+	// IDs is a comma separated list of task IDs, short IDs or unique ID prefixes.
+	IDs string `form:"ids" json:"ids"`
+	// Output is ExportOutputFile or ExportOutputZip; empty uses the format's default.
+	Output string `form:"output" json:"output"`
+}
+
+// ExportFormatInfo describes a registered export format.
+type ExportFormatInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Ext         string `json:"ext"`
+	ContentType string `json:"content_type"`
+	// Zip reports whether the format writes a zip of one file per task by default.
+	Zip bool `json:"zip"`
+}
@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// GitCommit is a commit read from a local git repository.
+type GitCommit struct {
+	Hash    string    `json:"hash"`
+	Author  string    `json:"author"`
+	Time    time.Time `json:"time"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body,omitempty"`
+}
+
+// CommitImportReq carries commits to attach to the tasks they reference.
+type CommitImportReq struct {
+	Commits []GitCommit `json:"commits"`
+	// DryRun reports what would be logged without writing anything.
+	DryRun bool `json:"dry_run"`
+}
+
+// CommitLog is a worklog for a task referenced by a commit.
+type CommitLog struct {
+	Hash      string `json:"hash"`
+	TaskID    string `json:"task_id"`
+	TaskTitle string `json:"task_title"`
+	LogID     string `json:"log_id"`
+	// Created is false when the commit was logged by an earlier scan.
+	Created bool `json:"created"`
+}
+
+// CommitRefError is a task reference in a commit message that matches no task, or
+// several.
+type CommitRefError struct {
+	Hash  string `json:"hash"`
+	Ref   string `json:"ref"`
+	Error string `json:"error"`
+}
+
+// CommitImportResult describes what attaching commits to tasks did.
+type CommitImportResult struct {
+	DryRun bool `json:"dry_run,omitempty"`
+	// Commits counts the commits read, with or without task references.
+	Commits int              `json:"commits"`
+	Logs    []CommitLog      `json:"logs"`
+	Errors  []CommitRefError `json:"errors"`
+}
@@ -0,0 +1,36 @@
+package model
+
+// Task list files that "chronicle import" reads besides dumps and CSV.
+const (
+	ImportFormatTodoTxt     = "todotxt"
+	ImportFormatTaskwarrior = "taskwarrior"
+	ImportFormatMarkdown    = "markdown"
+	ImportFormatGitHub      = "github"
+	ImportFormatGitLab      = "gitlab"
+	ImportFormatJira        = "jira"
+)
+
+// What importing a task does with it.
+const (
+	ImportActionCreate = "create"
+	ImportActionUpdate = "update"
+	ImportActionSkip   = "skip"
+)
+
+// TaskListImportReq controls the import of a task list file.
+type TaskListImportReq struct {
+	// Category is given to tasks without a project or heading (default "inbox").
+	Category string `form:"category" json:"category"`
+	// DryRun reports what would be imported without writing anything.
+	DryRun bool `form:"dry_run" json:"dry_run"`
+}
+
+// ImportedTask is a task read from a task list file.
+type ImportedTask struct {
+	Line   int    `json:"line"`
+	Action string `json:"action"`
+	Task   Task   `json:"task"`
+	// Logs counts the worklogs read with the task, e.g. Taskwarrior annotations or
+	// issue comments.
+	Logs int `json:"logs"`
+}
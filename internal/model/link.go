@@ -0,0 +1,175 @@
+package model
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Kinds of task links.
+const (
+	LinkKindIssue  = "issue"
+	LinkKindPR     = "pr"
+	LinkKindCommit = "commit"
+	LinkKindDoc    = "doc"
+	LinkKindLink   = "link"
+)
+
+// LinkKinds lists the valid link kinds.
+var LinkKinds = []string{LinkKindIssue, LinkKindPR, LinkKindCommit, LinkKindDoc, LinkKindLink}
+
+var (
+	linkPRPattern     = regexp.MustCompile(`/(pull|pulls|merge_requests|pull-requests)/\d+`)
+	linkCommitPattern = regexp.MustCompile(`/commits?/[0-9a-fA-F]{7,40}(/|$)`)
+	linkIssuePattern  = regexp.MustCompile(`/(issues|work_items)/\d+|/browse/[A-Z][A-Z0-9_]*-\d+`)
+	linkDocPattern    = regexp.MustCompile(`(?i)\.(md|pdf|docx?|pptx?|xlsx?)$|/wiki/`)
+	// linkMarkdownPattern matches a link written as [label](url).
+	linkMarkdownPattern = regexp.MustCompile(`^\[(.*)\]\((\S+)\)$`)
+)
+
+// TaskLink is an external reference of a task: an issue, pull request, commit,
+// document or any other URL.
+type TaskLink struct {
+	URL   string `json:"url"`
+	Kind  string `json:"kind"`
+	Label string `json:"label,omitempty"`
+}
+
+// String writes the link as [label](url), or just the url without a label.
+func (l TaskLink) String() string {
+	if l.Label == "" {
+		return l.URL
+	}
+	return "[" + l.Label + "](" + l.URL + ")"
+}
+
+// TaskLinks is stored as a JSON array in the links column. Older rows hold one URL
+// per line, which is still read; in JSON a string in that form is accepted too.
+type TaskLinks []TaskLink
+
+// String writes one link per line.
+func (ls TaskLinks) String() string {
+	lines := make([]string, len(ls))
+	for i, l := range ls {
+		lines[i] = l.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Has reports whether a link to rawURL is in the list.
+func (ls TaskLinks) Has(rawURL string) bool {
+	for _, l := range ls {
+		if l.URL == rawURL {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseLinks reads links written one per line, as a URL or [label](url); list
+// markers are ignored. Kinds are detected from the URLs.
+func ParseLinks(text string) TaskLinks {
+	var links TaskLinks
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimSpace(strings.TrimLeft(line, "-*+"))
+		if line == "" {
+			continue
+		}
+		l := TaskLink{URL: line}
+		if m := linkMarkdownPattern.FindStringSubmatch(line); m != nil {
+			l = TaskLink{URL: m[2], Label: strings.TrimSpace(m[1])}
+		}
+		l.Kind = DetectLinkKind(l.URL)
+		links = append(links, l)
+	}
+	return links
+}
+
+// DetectLinkKind guesses the kind of a link from its URL, e.g. "pr" for
+// https://github.com/owner/repo/pull/12.
+func DetectLinkKind(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return LinkKindLink
+	}
+	host := strings.ToLower(u.Hostname())
+	switch {
+	case linkPRPattern.MatchString(u.Path):
+		return LinkKindPR
+	case linkCommitPattern.MatchString(u.Path):
+		return LinkKindCommit
+	case linkIssuePattern.MatchString(u.Path):
+		return LinkKindIssue
+	case host == "docs.google.com", strings.HasPrefix(host, "docs."),
+		strings.HasSuffix(host, "notion.so"), strings.HasSuffix(host, "notion.site"),
+		linkDocPattern.MatchString(u.Path):
+		return LinkKindDoc
+	}
+	return LinkKindLink
+}
+
+// MarshalJSON writes no links as an empty array.
+func (ls TaskLinks) MarshalJSON() ([]byte, error) {
+	if ls == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]TaskLink(ls))
+}
+
+// UnmarshalJSON reads an array of links, or a string with one link per line.
+func (ls *TaskLinks) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*ls = ParseLinks(text)
+		return nil
+	}
+	var links []TaskLink
+	if err := json.Unmarshal(data, &links); err != nil {
+		return err
+	}
+	*ls = links
+	return nil
+}
+
+// Value stores the links as a JSON array, or an empty string if there are none.
+func (ls TaskLinks) Value() (driver.Value, error) {
+	if len(ls) == 0 {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode([]TaskLink(ls)); err != nil {
+		return nil, err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// Scan reads links stored by Value, or the one-per-line text of older rows.
+func (ls *TaskLinks) Scan(value interface{}) error {
+	var text string
+	switch v := value.(type) {
+	case nil:
+		*ls = nil
+		return nil
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into TaskLinks", value)
+	}
+
+	var links []TaskLink
+	if strings.HasPrefix(strings.TrimSpace(text), "[{") && json.Unmarshal([]byte(text), &links) == nil {
+		*ls = links
+		return nil
+	}
+	*ls = ParseLinks(text)
+	return nil
+}
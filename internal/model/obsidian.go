@@ -0,0 +1,15 @@
+package model
+
+// VaultSyncResult reports what a sync into an Obsidian vault changed. Paths are
+// relative to the vault.
+type VaultSyncResult struct {
+	Vault string `json:"vault"`
+	// Created, Updated and Renamed list task notes that were written; a renamed note
+	// (after its task's title changed) may also have been updated.
+	Created   []string `json:"created"`
+	Updated   []string `json:"updated"`
+	Renamed   []string `json:"renamed"`
+	Unchanged int      `json:"unchanged"`
+	// DailyNotes lists the daily notes whose chronicle section was written.
+	DailyNotes []string `json:"daily_notes"`
+}
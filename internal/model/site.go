@@ -0,0 +1,10 @@
+package model
+
+// SiteBuildResult reports what a static site build wrote. Paths are relative to Dir.
+type SiteBuildResult struct {
+	Dir   string   `json:"dir"`
+	Pages []string `json:"pages"`
+	// Removed lists pages of the previous build that were not written again: those
+	// of tasks that no longer exist or were archived.
+	Removed []string `json:"removed"`
+}
@@ -12,15 +12,19 @@ const (
 
 type Task struct {
 	ID                string     `gorm:"type:varchar(36);primaryKey" json:"id"`
+	ShortID           int        `gorm:"uniqueIndex" json:"short_id,omitempty"`
 	Title             string     `gorm:"type:varchar(255);not null" json:"title"`
 	Category          string     `gorm:"type:varchar(100);not null" json:"category"`
 	Description       string     `gorm:"type:text" json:"description,omitempty"`
 	Targets           string     `gorm:"type:text" json:"targets"`
-	Links             string     `gorm:"type:text" json:"links"`
+	Links             TaskLinks  `gorm:"type:text" json:"links"`
+	Tags              string     `gorm:"type:varchar(255)" json:"tags,omitempty"`
+	ParentID          string     `gorm:"type:varchar(36);index" json:"parent_id,omitempty"`
 	Status            string     `gorm:"type:varchar(20);default:'todo';not null" json:"status"`
 	Deadline          *time.Time `json:"deadline,omitempty"`
 	ActualCompletedAt *time.Time `json:"actual_completed_at,omitempty"`
 	ArchivedAt        *time.Time `gorm:"index" json:"archived_at,omitempty"`
+	ExternalRef       string     `gorm:"type:varchar(255);index" json:"external_ref,omitempty"`
 	CreatedAt         time.Time  `json:"created_at"`
 	UpdatedAt         time.Time  `json:"updated_at"`
 
@@ -35,6 +39,16 @@ type TaskLog struct {
 	CreatedAt    time.Time `json:"created_at"`
 }
 
+// TaskStatusChange records every status transition of a task, so flow metrics
+// (lead time, cycle time) can be derived after the fact.
+type TaskStatusChange struct {
+	ID         string    `gorm:"type:varchar(36);primaryKey" json:"id"`
+	TaskID     string    `gorm:"type:varchar(36);index;not null" json:"task_id"`
+	FromStatus string    `gorm:"type:varchar(20)" json:"from_status"`
+	ToStatus   string    `gorm:"type:varchar(20);not null" json:"to_status"`
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+}
+
 // Request and Response DTOs
 
 type CreateTaskReq struct {
@@ -42,7 +56,9 @@ type CreateTaskReq struct {
 	Category    string     `json:"category" binding:"required"`
 	Description string     `json:"description"`
 	Targets     string     `json:"targets"`
-	Links       string     `json:"links"`
+	Links       TaskLinks  `json:"links"`
+	Tags        string     `json:"tags"`
+	ParentID    string     `json:"parent_id"`
 	Deadline    *time.Time `json:"deadline"`
 }
 
@@ -58,13 +74,16 @@ type UpdateTaskReq struct {
 	Category    string     `json:"category"`
 	Description string     `json:"description"`
 	Targets     string     `json:"targets"`
-	Links       string     `json:"links"`
+	Links       *TaskLinks `json:"links,omitempty"`
+	Tags        *string    `json:"tags,omitempty"`
+	ParentID    *string    `json:"parent_id,omitempty"`
 	Status      string     `json:"status"`
 	Deadline    *time.Time `json:"deadline"`
 }
 
 type ActiveTaskResp struct {
 	ID       string     `json:"id"`
+	ShortID  int        `json:"short_id,omitempty"`
 	Title    string     `json:"title"`
 	Category string     `json:"category"`
 	Status   string     `json:"status"`
@@ -84,6 +103,37 @@ type DailySummaryResp struct {
 	Activities []DailySummaryActivity `json:"activities"`
 }
 
+type PeriodTaskActivity struct {
+	TaskID    string   `json:"task_id"`
+	TaskTitle string   `json:"task_title"`
+	Status    string   `json:"status"`
+	Logs      []string `json:"logs"`
+}
+
+type PeriodCategoryActivity struct {
+	Category string               `json:"category"`
+	Tasks    []PeriodTaskActivity `json:"tasks"`
+}
+
+// PeriodTaskRef points at a task that reached a milestone (completed, started,
+// slipped) in the period; At is the moment of that milestone.
+type PeriodTaskRef struct {
+	TaskID    string `json:"task_id"`
+	TaskTitle string `json:"task_title"`
+	Category  string `json:"category"`
+	At        string `json:"at"`
+}
+
+type PeriodSummaryResp struct {
+	From       string                   `json:"from"`
+	To         string                   `json:"to"`
+	TotalLogs  int                      `json:"total_logs"`
+	Categories []PeriodCategoryActivity `json:"categories"`
+	Completed  []PeriodTaskRef          `json:"completed"`
+	Started    []PeriodTaskRef          `json:"started"`
+	Slipped    []PeriodTaskRef          `json:"slipped"`
+}
+
 type StatsSummaryResp struct {
 	TotalTasks      int            `json:"total_tasks"`
 	CompletedTasks  int            `json:"completed_tasks"`
@@ -100,6 +150,81 @@ type DailyStats struct {
 	Created   int    `json:"created"`
 }
 
+// DurationStats summarises a set of durations, expressed in hours.
+type DurationStats struct {
+	Count    int     `json:"count"`
+	AvgHours float64 `json:"avg_hours"`
+	P50Hours float64 `json:"p50_hours"`
+	P85Hours float64 `json:"p85_hours"`
+	P95Hours float64 `json:"p95_hours"`
+}
+
+type CategoryFlowStats struct {
+	Completed int           `json:"completed"`
+	LeadTime  DurationStats `json:"lead_time"`
+	CycleTime DurationStats `json:"cycle_time"`
+}
+
+type WeeklyThroughput struct {
+	WeekStart string `json:"week_start"`
+	Completed int    `json:"completed"`
+}
+
+type FlowStatsResp struct {
+	Since      string                       `json:"since"`
+	LeadTime   DurationStats                `json:"lead_time"`
+	CycleTime  DurationStats                `json:"cycle_time"`
+	ByCategory map[string]CategoryFlowStats `json:"by_category"`
+	Throughput []WeeklyThroughput           `json:"throughput"`
+}
+
+type CFDPoint struct {
+	Date       string `json:"date"`
+	Todo       int    `json:"todo"`
+	InProgress int    `json:"in_progress"`
+	Done       int    `json:"done"`
+}
+
+type CFDResp struct {
+	From     string     `json:"from"`
+	To       string     `json:"to"`
+	Category string     `json:"category,omitempty"`
+	Tag      string     `json:"tag,omitempty"`
+	Parent   string     `json:"parent,omitempty"`
+	Points   []CFDPoint `json:"points"`
+}
+
+type BurndownPoint struct {
+	Date      string  `json:"date"`
+	Scope     int     `json:"scope"`
+	Remaining int     `json:"remaining"`
+	Ideal     float64 `json:"ideal"`
+}
+
+type BurndownResp struct {
+	From     string          `json:"from"`
+	To       string          `json:"to"`
+	Category string          `json:"category,omitempty"`
+	Tag      string          `json:"tag,omitempty"`
+	Parent   string          `json:"parent,omitempty"`
+	Points   []BurndownPoint `json:"points"`
+}
+
+type HeatmapDay struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
+
+type HeatmapResp struct {
+	From          string       `json:"from"`
+	To            string       `json:"to"`
+	TotalLogs     int          `json:"total_logs"`
+	ActiveDays    int          `json:"active_days"`
+	CurrentStreak int          `json:"current_streak"`
+	LongestStreak int          `json:"longest_streak"`
+	Days          []HeatmapDay `json:"days"`
+}
+
 type StandardResponse struct {
 	Code int         `json:"code"`
 	Msg  string      `json:"msg"`
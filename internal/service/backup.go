@@ -0,0 +1,188 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// ErrBackupUnsupported is returned for databases other than SQLite, which should be
+// backed up with their own tools (pg_dump, mysqldump).
+var ErrBackupUnsupported = errors.New("backup and restore are only supported for the sqlite driver")
+
+// Backup writes a consistent copy of the open database to path using VACUUM INTO,
+// which is safe while the server keeps writing.
+func Backup(path string) error {
+	if DB == nil || DB.Dialector.Name() != DriverSQLite {
+		return ErrBackupUnsupported
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return DB.Exec("VACUUM INTO ?", path).Error
+}
+
+// VerifyBackup checks that path is an intact chronicle SQLite database this binary
+// can open, and returns its schema version.
+func VerifyBackup(path string) (int, error) {
+	if _, err := os.Stat(path); err != nil {
+		return 0, err
+	}
+	// Failures are reported through the returned error, not the query log
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return 0, err
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+
+	var result string
+	if err := db.Raw("PRAGMA integrity_check").Scan(&result).Error; err != nil {
+		return 0, fmt.Errorf("%s is not a valid SQLite database: %w", path, err)
+	}
+	if result != "ok" {
+		return 0, fmt.Errorf("integrity check failed for %s: %s", path, result)
+	}
+	if !db.Migrator().HasTable("tasks") {
+		return 0, fmt.Errorf("%s is not a chronicle database: no tasks table", path)
+	}
+
+	// Backups taken before versioned migrations have no schema_migrations table
+	version := 0
+	if db.Migrator().HasTable(&SchemaMigration{}) {
+		if version, err = SchemaVersion(db); err != nil {
+			return 0, err
+		}
+	}
+	if latest := LatestSchemaVersion(); version > latest {
+		return version, fmt.Errorf("backup schema version %d is newer than this binary supports (%d); upgrade chronicle", version, latest)
+	}
+	return version, nil
+}
+
+// Restore verifies the backup at path and replaces the SQLite database at dbPath with
+// it. The current database is kept next to it and its path returned (empty if there
+// was none). The database must not be open, so stop the server first.
+func Restore(path, dbPath string) (string, error) {
+	if _, err := VerifyBackup(path); err != nil {
+		return "", err
+	}
+
+	// Copy next to the target first so the final rename is atomic
+	tmp := dbPath + ".restore"
+	if err := copyFile(path, tmp); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+
+	var previous string
+	if _, err := os.Stat(dbPath); err == nil {
+		previous = dbPath + ".before-restore-" + time.Now().Format("20060102-150405")
+		if err := os.Rename(dbPath, previous); err != nil {
+			os.Remove(tmp)
+			return "", err
+		}
+	}
+	// Journals of the old database must not be replayed onto the restored one
+	for _, suffix := range []string{"-journal", "-wal", "-shm"} {
+		os.Remove(dbPath + suffix)
+	}
+
+	if err := os.Rename(tmp, dbPath); err != nil {
+		return previous, err
+	}
+	return previous, nil
+}
+
+// Snapshot names are <kind>-<period>.db, e.g. daily-2026-03-01.db or weekly-2026-W09.db.
+const (
+	snapshotDaily  = "daily"
+	snapshotWeekly = "weekly"
+)
+
+// TakeSnapshots writes today's daily and this week's weekly snapshot into dir unless
+// they already exist, then deletes all but the newest keepDaily / keepWeekly of each.
+// A keep count of 0 disables that kind. Days and weeks are delimited in loc. Returns
+// the paths of the snapshots written.
+func TakeSnapshots(dir string, now time.Time, keepDaily, keepWeekly int, loc *time.Location) ([]string, error) {
+	now = now.In(loc)
+	year, week := now.ISOWeek()
+
+	kinds := []struct {
+		kind   string
+		period string
+		keep   int
+	}{
+		{snapshotDaily, now.Format("2006-01-02"), keepDaily},
+		{snapshotWeekly, fmt.Sprintf("%d-W%02d", year, week), keepWeekly},
+	}
+
+	var written []string
+	for _, k := range kinds {
+		if k.keep <= 0 {
+			continue
+		}
+		path := filepath.Join(dir, k.kind+"-"+k.period+".db")
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			if err := Backup(path); err != nil {
+				return written, err
+			}
+			written = append(written, path)
+		}
+		if err := pruneSnapshots(dir, k.kind, k.keep); err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// pruneSnapshots deletes all but the newest keep snapshots of a kind. Periods sort
+// chronologically as strings.
+func pruneSnapshots(dir, kind string, keep int) error {
+	matches, err := filepath.Glob(filepath.Join(dir, kind+"-*.db"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(matches)
+	for len(matches) > keep {
+		if err := os.Remove(matches[0]); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		matches = matches[1:]
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yuyudeqiu/chronicle/internal/model"
+)
+
+// ErrInvalidBudget is returned for an agent context budget below MinContextBudget.
+var ErrInvalidBudget = errors.New("invalid budget")
+
+// contextDueSoon is how far ahead the agent context lists upcoming deadlines.
+const contextDueSoon = 7 * 24 * time.Hour
+
+// Longest titles and worklog texts in the agent context, in characters.
+const (
+	contextTitleLen = 80
+	contextLogLen   = 100
+	contextTodayLen = 240
+)
+
+// GetAgentContext returns a snapshot of the work at hand as of now, in priority
+// order: overdue tasks, tasks in progress with their last worklog, todo tasks due in
+// the next 7 days and today's worklogs. Items are dropped from the end until the
+// snapshot written as text fits in budget estimated tokens; every non-empty section
+// keeps its heading and says how many items it left out. Days are delimited in loc.
+func GetAgentContext(budget int, now time.Time, loc *time.Location) (*model.AgentContextResp, error) {
+	if budget < model.MinContextBudget {
+		return nil, fmt.Errorf("%w: %d (at least %d tokens)", ErrInvalidBudget, budget, model.MinContextBudget)
+	}
+	now = now.In(loc)
+
+	var tasks []model.Task
+	if err := DB.Where("status IN ? AND archived_at IS NULL", []string{model.TaskStatusTodo, model.TaskStatusInProgress}).
+		Order("created_at asc, id asc").
+		Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+
+	resp := &model.AgentContextResp{Date: now.Format("2006-01-02"), Budget: budget}
+	var overdue, inProgress, dueSoon []model.Task
+	for _, t := range tasks {
+		if t.Status == model.TaskStatusInProgress {
+			resp.InProgress++
+		} else {
+			resp.Todo++
+		}
+		switch {
+		case t.Deadline != nil && t.Deadline.Before(now):
+			overdue = append(overdue, t)
+		case t.Status == model.TaskStatusInProgress:
+			inProgress = append(inProgress, t)
+		case t.Deadline != nil && t.Deadline.Before(now.Add(contextDueSoon)):
+			dueSoon = append(dueSoon, t)
+		}
+	}
+
+	lastLogs, err := lastWorklogs(append(append([]model.Task{}, overdue...), inProgress...))
+	if err != nil {
+		return nil, err
+	}
+	lastActivity := func(t model.Task) time.Time {
+		if l, ok := lastLogs[t.ID]; ok {
+			return l.CreatedAt
+		}
+		return t.UpdatedAt
+	}
+	sort.SliceStable(overdue, func(i, j int) bool { return overdue[i].Deadline.Before(*overdue[j].Deadline) })
+	sort.SliceStable(inProgress, func(i, j int) bool { return lastActivity(inProgress[i]).After(lastActivity(inProgress[j])) })
+	sort.SliceStable(dueSoon, func(i, j int) bool { return dueSoon[i].Deadline.Before(*dueSoon[j].Deadline) })
+
+	items := make(map[string][]string)
+	for _, t := range overdue {
+		items[model.ContextSectionOverdue] = append(items[model.ContextSectionOverdue],
+			fmt.Sprintf("%s [%s, %s] due %s%s", contextTaskLabel(t), t.Status, t.Category, contextTime(*t.Deadline, now), contextLastLog(lastLogs, t.ID, now)))
+	}
+	for _, t := range inProgress {
+		due := ""
+		if t.Deadline != nil {
+			due = " due " + contextTime(*t.Deadline, now)
+		}
+		items[model.ContextSectionInProgress] = append(items[model.ContextSectionInProgress],
+			fmt.Sprintf("%s [%s]%s%s", contextTaskLabel(t), t.Category, due, contextLastLog(lastLogs, t.ID, now)))
+	}
+	for _, t := range dueSoon {
+		items[model.ContextSectionDueSoon] = append(items[model.ContextSectionDueSoon],
+			fmt.Sprintf("%s [%s] due %s", contextTaskLabel(t), t.Category, contextTime(*t.Deadline, now)))
+	}
+	today, err := contextToday(now, loc)
+	if err != nil {
+		return nil, err
+	}
+	items[model.ContextSectionToday] = today
+
+	for _, name := range []string{model.ContextSectionOverdue, model.ContextSectionInProgress, model.ContextSectionDueSoon, model.ContextSectionToday} {
+		if n := len(items[name]); n > 0 {
+			resp.Sections = append(resp.Sections, model.AgentContextSection{Name: name, Total: n, Items: []string{}})
+		}
+	}
+	if resp.Sections == nil {
+		resp.Sections = []model.AgentContextSection{}
+	}
+	fitContext(resp, items)
+	return resp, nil
+}
+
+// fitContext fills the sections of resp with items in priority order until the next
+// one would exceed the budget. The headings, markers for omitted items and the
+// truncation footer are reserved first, at their largest.
+func fitContext(resp *model.AgentContextResp, items map[string][]string) {
+	empty := *resp
+	empty.Sections = make([]model.AgentContextSection, len(resp.Sections))
+	used := contextLineTokens(resp.Header())
+	for i, s := range resp.Sections {
+		s.Omitted = s.Total
+		empty.Sections[i] = s
+		used += contextLineTokens(s.Title()) + contextLineTokens(s.Marker())
+	}
+	used += contextLineTokens(empty.Footer())
+
+	stopped := false
+	for i := range resp.Sections {
+		s := &resp.Sections[i]
+		for _, item := range items[s.Name] {
+			cost := contextLineTokens("- " + item)
+			if stopped || used+cost > resp.Budget {
+				stopped = true
+				break
+			}
+			s.Items = append(s.Items, item)
+			used += cost
+		}
+		s.Omitted = s.Total - len(s.Items)
+		if s.Omitted > 0 {
+			resp.Truncated = true
+		}
+	}
+	resp.Tokens = estimateTokens(resp.Text())
+}
+
+// lastWorklogs returns the newest worklog of each task that has any.
+func lastWorklogs(tasks []model.Task) (map[string]model.TaskLog, error) {
+	last := make(map[string]model.TaskLog, len(tasks))
+	if len(tasks) == 0 {
+		return last, nil
+	}
+	ids := make([]string, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID
+	}
+
+	var logs []model.TaskLog
+	if err := DB.Where("task_id IN ?", ids).Order("created_at desc, id desc").Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	for _, l := range logs {
+		if _, ok := last[l.TaskID]; !ok {
+			last[l.TaskID] = l
+		}
+	}
+	return last, nil
+}
+
+// contextToday lists the tasks worked on today with their worklogs, one line each.
+func contextToday(now time.Time, loc *time.Location) ([]string, error) {
+	summary, err := GetDailySummary(now.Format("2006-01-02"), loc)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(summary.Activities))
+	for i, a := range summary.Activities {
+		ids[i] = a.TaskID
+	}
+	tasks, err := GetTasksByID(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, a := range summary.Activities {
+		t, ok := tasks[a.TaskID]
+		if !ok {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s [%s]: %s", contextTaskLabel(t), t.Status, clipText(strings.Join(a.TodayLogs, "; "), contextTodayLen)))
+	}
+	return lines, nil
+}
+
+// contextTaskLabel names a task by its short id and title.
+func contextTaskLabel(t model.Task) string {
+	id := fmt.Sprintf("#%d", t.ShortID)
+	if t.ShortID == 0 {
+		id = t.ID
+		if len(id) > 8 {
+			id = id[:8]
+		}
+	}
+	return id + " " + clipText(t.Title, contextTitleLen)
+}
+
+func contextLastLog(last map[string]model.TaskLog, taskID string, now time.Time) string {
+	l, ok := last[taskID]
+	if !ok {
+		return " · no worklogs"
+	}
+	return fmt.Sprintf(" · last %s: %s", contextTime(l.CreatedAt, now), clipText(l.LogText, contextLogLen))
+}
+
+// contextTime writes t in the location of now, leaving out the year if it is now's.
+func contextTime(t, now time.Time) string {
+	t = t.In(now.Location())
+	if t.Year() != now.Year() {
+		return t.Format("2006-01-02 15:04")
+	}
+	return t.Format("01-02 15:04")
+}
+
+// clipText puts s on one line and cuts it to at most n characters.
+func clipText(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
+
+// contextLineTokens estimates a line of the snapshot including its newline.
+func contextLineTokens(line string) int {
+	return estimateTokens(line + "\n")
+}
+
+// estimateTokens approximates how many tokens s costs a language model: about four
+// characters per token for ASCII text, one per character otherwise (e.g. Chinese).
+func estimateTokens(s string) int {
+	ascii, other := 0, 0
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
@@ -1,25 +1,90 @@
 package service
 
 import (
+	"fmt"
 	"log"
 
-	"github.com/yuyudeqiu/chronicle/internal/model"
 	"github.com/glebarez/sqlite"
+	gomysql "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
+// Supported database drivers.
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+)
+
 var DB *gorm.DB
 
-func InitDB(dsn string) {
-	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+// InitDB opens the database and checks its schema. A new, empty database is set up
+// with all migrations; pending migrations on an existing one are applied only when
+// autoMigrate is set, otherwise it refuses to start until `chronicle migrate up` has
+// been run. It also refuses a database migrated by a newer version of chronicle.
+func InitDB(driver, dsn string, autoMigrate bool) {
+	db, err := OpenDB(driver, dsn)
 	if err != nil {
 		log.Fatalf("Failed to connect database: %v", err)
 	}
 
-	err = db.AutoMigrate(&model.Task{}, &model.TaskLog{})
+	applied, err := prepareSchema(db, autoMigrate)
 	if err != nil {
-		log.Fatalf("Failed to auto migrate database: %v", err)
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	for _, m := range applied {
+		log.Printf("Applied migration %d: %s", m.Version, m.Name)
 	}
 
 	DB = db
 }
+
+// OpenDB opens the database without applying migrations, creating only the
+// schema_migrations bookkeeping table.
+func OpenDB(driver, dsn string) (*gorm.DB, error) {
+	dialector, err := openDialector(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	// TranslateError maps unique violations of every backend to gorm.ErrDuplicatedKey
+	db, err := gorm.Open(dialector, &gorm.Config{TranslateError: true})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func openDialector(driver, dsn string) (gorm.Dialector, error) {
+	switch driver {
+	case DriverSQLite:
+		return sqlite.Open(dsn), nil
+	case DriverPostgres:
+		return postgres.Open(dsn), nil
+	case DriverMySQL:
+		// DATETIME columns can only be scanned into time.Time with parseTime enabled
+		cfg, err := gomysql.ParseDSN(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mysql dsn: %w", err)
+		}
+		cfg.ParseTime = true
+		return mysql.New(mysql.Config{DSNConfig: cfg}), nil
+	}
+	return nil, fmt.Errorf("unsupported database driver %q (supported: %s, %s, %s)", driver, DriverSQLite, DriverPostgres, DriverMySQL)
+}
+
+// CloseDB closes the underlying database connection.
+func CloseDB() error {
+	if DB == nil {
+		return nil
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
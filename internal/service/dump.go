@@ -0,0 +1,310 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yuyudeqiu/chronicle/internal/model"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidDump is returned when a dump fails validation; nothing is imported.
+	ErrInvalidDump = errors.New("invalid dump")
+	// ErrUnsupportedImportMode is returned for modes other than merge and replace.
+	ErrUnsupportedImportMode = errors.New("unsupported import mode")
+
+	// errDryRun rolls back the transaction of a dry-run import.
+	errDryRun = errors.New("dry run")
+)
+
+// maxDumpProblems limits how many validation problems are reported at once.
+const maxDumpProblems = 10
+
+// ExportDump returns every task, worklog and status change in the database.
+func ExportDump() (*model.Dump, error) {
+	version, err := SchemaVersion(DB)
+	if err != nil {
+		return nil, err
+	}
+
+	dump := &model.Dump{
+		Format:        model.DumpFormat,
+		Version:       model.DumpVersion,
+		SchemaVersion: version,
+		ExportedAt:    time.Now(),
+		Tasks:         []model.Task{},
+		Logs:          []model.TaskLog{},
+		StatusChanges: []model.TaskStatusChange{},
+	}
+	if err := DB.Order("created_at asc, id asc").Find(&dump.Tasks).Error; err != nil {
+		return nil, err
+	}
+	if err := DB.Order("created_at asc, id asc").Find(&dump.Logs).Error; err != nil {
+		return nil, err
+	}
+	if err := DB.Order("created_at asc, id asc").Find(&dump.StatusChanges).Error; err != nil {
+		return nil, err
+	}
+	return dump, nil
+}
+
+// ImportDump validates d and imports it in a single transaction. In merge mode,
+// existing records are kept; tasks are replaced only when the imported copy has a
+// newer updated_at. In replace mode all existing data is deleted first.
+func ImportDump(d *model.Dump, mode string) (*model.ImportResult, error) {
+	return importDump(d, mode, false)
+}
+
+// DryRunImport reports what ImportDump would do, without changing the database:
+// the import runs as usual and its transaction is rolled back.
+func DryRunImport(d *model.Dump, mode string) (*model.ImportResult, error) {
+	return importDump(d, mode, true)
+}
+
+func importDump(d *model.Dump, mode string, dryRun bool) (*model.ImportResult, error) {
+	if mode == "" {
+		mode = model.ImportModeMerge
+	}
+	if mode != model.ImportModeMerge && mode != model.ImportModeReplace {
+		return nil, fmt.Errorf("%w: %s (expected %s or %s)", ErrUnsupportedImportMode, mode, model.ImportModeMerge, model.ImportModeReplace)
+	}
+	if err := validateDump(d, mode); err != nil {
+		return nil, err
+	}
+
+	result := &model.ImportResult{Mode: mode}
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if mode == model.ImportModeReplace {
+			all := tx.Session(&gorm.Session{AllowGlobalUpdate: true})
+			for _, table := range []interface{}{&model.TaskStatusChange{}, &model.TaskLog{}, &model.Task{}} {
+				if err := all.Delete(table).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, t := range d.Tasks {
+			t.Logs = nil
+			localizeTask(&t)
+
+			var existing model.Task
+			if err := tx.Where("id = ?", t.ID).Limit(1).Find(&existing).Error; err != nil {
+				return err
+			}
+			if existing.ID != "" {
+				if !t.UpdatedAt.After(existing.UpdatedAt) {
+					result.Tasks.Skipped++
+					continue
+				}
+				// Delete and re-create rather than update, so updated_at is kept as exported
+				if err := tx.Delete(&model.Task{}, "id = ?", t.ID).Error; err != nil {
+					return err
+				}
+				result.Tasks.Updated++
+			} else {
+				result.Tasks.Created++
+			}
+			if err := importShortID(tx, &t, existing); err != nil {
+				return fmt.Errorf("task %s: %w", t.ID, err)
+			}
+			if err := tx.Create(&t).Error; err != nil {
+				return fmt.Errorf("task %s: %w", t.ID, err)
+			}
+			// Importers that only know the current status send no history; record
+			// the change so flow charts see it
+			if existing.ID != "" && existing.Status != t.Status && !hasTransition(d.StatusChanges, t.ID, t.Status) {
+				if err := recordStatusChange(tx, t.ID, existing.Status, t.Status, t.UpdatedAt); err != nil {
+					return fmt.Errorf("task %s: %w", t.ID, err)
+				}
+				result.StatusChanges.Created++
+			}
+		}
+
+		for _, l := range d.Logs {
+			l.CreatedAt = l.CreatedAt.Local()
+			created, err := createIfMissing(tx, &model.TaskLog{}, l.ID, &l)
+			if err != nil {
+				return fmt.Errorf("log %s: %w", l.ID, err)
+			}
+			countImport(&result.Logs, created)
+		}
+
+		for _, c := range d.StatusChanges {
+			c.CreatedAt = c.CreatedAt.Local()
+			created, err := createIfMissing(tx, &model.TaskStatusChange{}, c.ID, &c)
+			if err != nil {
+				return fmt.Errorf("status change %s: %w", c.ID, err)
+			}
+			countImport(&result.StatusChanges, created)
+		}
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	return result, nil
+}
+
+// hasTransition reports whether changes include a move of task taskID to status.
+func hasTransition(changes []model.TaskStatusChange, taskID, status string) bool {
+	for _, c := range changes {
+		if c.TaskID == taskID && c.ToStatus == status {
+			return true
+		}
+	}
+	return false
+}
+
+// importShortID keeps the short id of a task that already exists here, and the one
+// from the dump if no other task has it; otherwise the task gets the next one.
+// Older dumps have no short ids.
+func importShortID(tx *gorm.DB, t *model.Task, existing model.Task) error {
+	if existing.ID != "" && existing.ShortID > 0 {
+		t.ShortID = existing.ShortID
+		return nil
+	}
+	if t.ShortID > 0 {
+		var taken int64
+		if err := tx.Model(&model.Task{}).Where("short_id = ?", t.ShortID).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken == 0 {
+			return reserveShortID(tx, t.ShortID)
+		}
+	}
+	var err error
+	t.ShortID, err = nextShortID(tx)
+	return err
+}
+
+// validateDump checks the dump as a whole before anything is written. References
+// to tasks outside the dump are allowed in merge mode if the task already exists.
+func validateDump(d *model.Dump, mode string) error {
+	if d.Format != model.DumpFormat {
+		return fmt.Errorf("%w: format is %q, expected %q", ErrInvalidDump, d.Format, model.DumpFormat)
+	}
+	if d.Version < 1 || d.Version > model.DumpVersion {
+		return fmt.Errorf("%w: unsupported dump version %d (this binary reads up to %d)", ErrInvalidDump, d.Version, model.DumpVersion)
+	}
+
+	var problems []string
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	validStatus := map[string]bool{model.TaskStatusTodo: true, model.TaskStatusInProgress: true, model.TaskStatusDone: true}
+	taskIDs := make(map[string]bool, len(d.Tasks))
+	for i, t := range d.Tasks {
+		switch {
+		case t.ID == "":
+			problem("tasks[%d]: missing id", i)
+		case taskIDs[t.ID]:
+			problem("tasks[%d]: duplicate id %s", i, t.ID)
+		}
+		taskIDs[t.ID] = true
+		if t.Title == "" || t.Category == "" {
+			problem("tasks[%d]: title and category are required", i)
+		}
+		if !validStatus[t.Status] {
+			problem("tasks[%d]: invalid status %q", i, t.Status)
+		}
+	}
+
+	// Task IDs referenced by logs or status changes but not present in the dump
+	missing := make(map[string]bool)
+	checkRef := func(kind string, i int, id, taskID string, seen map[string]bool) {
+		switch {
+		case id == "":
+			problem("%s[%d]: missing id", kind, i)
+		case seen[id]:
+			problem("%s[%d]: duplicate id %s", kind, i, id)
+		}
+		seen[id] = true
+		if taskID == "" {
+			problem("%s[%d]: missing task_id", kind, i)
+		} else if !taskIDs[taskID] {
+			missing[taskID] = true
+		}
+	}
+	logIDs := make(map[string]bool, len(d.Logs))
+	for i, l := range d.Logs {
+		checkRef("logs", i, l.ID, l.TaskID, logIDs)
+	}
+	changeIDs := make(map[string]bool, len(d.StatusChanges))
+	for i, c := range d.StatusChanges {
+		checkRef("status_changes", i, c.ID, c.TaskID, changeIDs)
+		if !validStatus[c.ToStatus] {
+			problem("status_changes[%d]: invalid to_status %q", i, c.ToStatus)
+		}
+	}
+
+	if len(missing) > 0 {
+		ids := make([]string, 0, len(missing))
+		for id := range missing {
+			ids = append(ids, id)
+		}
+		var found []string
+		if mode == model.ImportModeMerge {
+			if err := DB.Model(&model.Task{}).Where("id IN ?", ids).Pluck("id", &found).Error; err != nil {
+				return err
+			}
+		}
+		exists := make(map[string]bool, len(found))
+		for _, id := range found {
+			exists[id] = true
+		}
+		for _, id := range ids {
+			if !exists[id] {
+				problem("unknown task_id %s", id)
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	if len(problems) > maxDumpProblems {
+		problems = append(problems[:maxDumpProblems], fmt.Sprintf("and %d more", len(problems)-maxDumpProblems))
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidDump, strings.Join(problems, "; "))
+}
+
+// localizeTask converts timestamps to the process-local zone, in which SQLite
+// values are stored and compared (see dbTime).
+func localizeTask(t *model.Task) {
+	t.CreatedAt = t.CreatedAt.Local()
+	t.UpdatedAt = t.UpdatedAt.Local()
+	for _, p := range []**time.Time{&t.Deadline, &t.ActualCompletedAt, &t.ArchivedAt} {
+		if *p != nil {
+			local := (*p).Local()
+			*p = &local
+		}
+	}
+}
+
+// createIfMissing inserts record unless a row with the same id exists in the table
+// of model, and reports whether it was inserted.
+func createIfMissing(tx *gorm.DB, table interface{}, id string, record interface{}) (bool, error) {
+	var count int64
+	if err := tx.Model(table).Where("id = ?", id).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+	return true, tx.Create(record).Error
+}
+
+func countImport(c *model.ImportCounts, created bool) {
+	if created {
+		c.Created++
+	} else {
+		c.Skipped++
+	}
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yuyudeqiu/chronicle/internal/model"
+)
+
+func TestDumpRoundTrip(t *testing.T) {
+	setupTestDB(t)
+
+	task := createTestTask(t, "round trip", "dev")
+	if err := UpdateProgress(task.ID, model.UpdateProgressReq{LogText: "progress", NewStatus: model.TaskStatusInProgress}); err != nil {
+		t.Fatalf("UpdateProgress: %v", err)
+	}
+	if err := ArchiveTask(task.ID); err != nil {
+		t.Fatalf("ArchiveTask: %v", err)
+	}
+
+	dump, err := ExportDump()
+	if err != nil {
+		t.Fatalf("ExportDump: %v", err)
+	}
+	if len(dump.Tasks) != 1 || len(dump.Logs) != 1 || len(dump.StatusChanges) != 2 {
+		t.Fatalf("dump has %d tasks, %d logs, %d status changes; want 1, 1, 2", len(dump.Tasks), len(dump.Logs), len(dump.StatusChanges))
+	}
+
+	result, err := ImportDump(dump, model.ImportModeReplace)
+	if err != nil {
+		t.Fatalf("ImportDump: %v", err)
+	}
+	if result.Tasks.Created != 1 || result.Logs.Created != 1 || result.StatusChanges.Created != 2 {
+		t.Errorf("replace import = %+v, want everything re-created", result)
+	}
+
+	got, err := GetTask(task.ID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	want := dump.Tasks[0]
+	if got.ShortID != want.ShortID || got.Status != want.Status || got.ArchivedAt == nil {
+		t.Errorf("imported task = #%d %s archived %v, want #%d %s archived", got.ShortID, got.Status, got.ArchivedAt, want.ShortID, want.Status)
+	}
+	// Backends store timestamps with different precision
+	if d := got.UpdatedAt.Sub(want.UpdatedAt); d < -time.Second || d > time.Second {
+		t.Errorf("updated_at = %s, want %s", got.UpdatedAt, want.UpdatedAt)
+	}
+
+	result, err = ImportDump(dump, model.ImportModeMerge)
+	if err != nil {
+		t.Fatalf("ImportDump merge: %v", err)
+	}
+	if result.Tasks.Skipped != 1 || result.Logs.Created != 0 || result.StatusChanges.Created != 0 {
+		t.Errorf("merging the same dump again = %+v, want nothing imported", result)
+	}
+}
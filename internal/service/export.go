@@ -0,0 +1,120 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yuyudeqiu/chronicle/internal/model"
+)
+
+// ErrInvalidFilter is returned for task selection filters that cannot be parsed.
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// ExportSelection returns the tasks selected by req with their worklogs and status
+// changes, as a dump that can be imported elsewhere. Dates are days in loc.
+func ExportSelection(req model.ExportReq, loc *time.Location) (*model.Dump, error) {
+	query := DB.Model(&model.Task{})
+	if req.IDs != "" {
+		var ids []string
+		for _, id := range strings.Split(req.IDs, ",") {
+			if id = strings.TrimSpace(id); id == "" {
+				continue
+			}
+			resolved, err := ResolveTaskID(id)
+			if errors.Is(err, ErrTaskNotFound) || errors.Is(err, ErrAmbiguousTaskID) {
+				return nil, fmt.Errorf("%w: ids: %v", ErrInvalidFilter, err)
+			}
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, resolved)
+		}
+		query = query.Where("id IN ?", ids)
+	}
+	if req.Category != "" {
+		query = query.Where("category = ?", req.Category)
+	}
+
+	from, to, err := completedRange(req, loc)
+	if err != nil {
+		return nil, err
+	}
+	if from != nil || to != nil {
+		query = query.Where("status = ?", model.TaskStatusDone)
+	}
+	if from != nil {
+		query = query.Where("actual_completed_at >= ?", dbTime(*from))
+	}
+	if to != nil {
+		query = query.Where("actual_completed_at < ?", dbTime(*to))
+	}
+
+	version, err := SchemaVersion(DB)
+	if err != nil {
+		return nil, err
+	}
+	dump := &model.Dump{
+		Format:        model.DumpFormat,
+		Version:       model.DumpVersion,
+		SchemaVersion: version,
+		ExportedAt:    time.Now(),
+		Tasks:         []model.Task{},
+		Logs:          []model.TaskLog{},
+		StatusChanges: []model.TaskStatusChange{},
+	}
+	if err := query.Order("created_at asc, id asc").Find(&dump.Tasks).Error; err != nil {
+		return nil, err
+	}
+	if len(dump.Tasks) == 0 {
+		return dump, nil
+	}
+
+	ids := make([]string, 0, len(dump.Tasks))
+	for _, t := range dump.Tasks {
+		ids = append(ids, t.ID)
+	}
+	if err := DB.Where("task_id IN ?", ids).Order("created_at asc, id asc").Find(&dump.Logs).Error; err != nil {
+		return nil, err
+	}
+	if err := DB.Where("task_id IN ?", ids).Order("created_at asc, id asc").Find(&dump.StatusChanges).Error; err != nil {
+		return nil, err
+	}
+	return dump, nil
+}
+
+// completedRange returns the [from, to) bounds of the completion filter; nil bounds
+// are open.
+func completedRange(req model.ExportReq, loc *time.Location) (*time.Time, *time.Time, error) {
+	if req.Date != "" {
+		if req.From != "" || req.To != "" {
+			return nil, nil, fmt.Errorf("%w: date cannot be combined with from / to", ErrInvalidFilter)
+		}
+		start, end, err := DayBounds(req.Date, loc)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: date %q: expected YYYY-MM-DD", ErrInvalidFilter, req.Date)
+		}
+		return &start, &end, nil
+	}
+
+	var from, to *time.Time
+	if req.From != "" {
+		start, _, err := DayBounds(req.From, loc)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: from %q: expected YYYY-MM-DD", ErrInvalidFilter, req.From)
+		}
+		from = &start
+	}
+	if req.To != "" {
+		_, end, err := DayBounds(req.To, loc)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: to %q: expected YYYY-MM-DD", ErrInvalidFilter, req.To)
+		}
+		to = &end
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, nil, fmt.Errorf("%w: from (%s) is after to (%s)", ErrInvalidFilter, req.From, req.To)
+	}
+	return from, to, nil
+}
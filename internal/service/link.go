@@ -0,0 +1,103 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/yuyudeqiu/chronicle/internal/model"
+)
+
+// ErrInvalidLink is returned for a task link that is not an http(s) URL or has an
+// unknown kind.
+var ErrInvalidLink = errors.New("invalid link")
+
+// NormalizeLinks validates links and removes duplicates, keeping the first of each
+// URL (with the label of a later one if it has none). Scheme and host are lower-cased
+// and missing kinds detected from the URL.
+func NormalizeLinks(links model.TaskLinks) (model.TaskLinks, error) {
+	normalized := make(model.TaskLinks, 0, len(links))
+	index := make(map[string]int, len(links))
+	for _, l := range links {
+		u, ok := normalizeLinkURL(l.URL)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q is not an http(s) URL", ErrInvalidLink, l.URL)
+		}
+		l.URL = u
+		l.Label = strings.TrimSpace(l.Label)
+		l.Kind = strings.ToLower(strings.TrimSpace(l.Kind))
+		if l.Kind == "" {
+			l.Kind = model.DetectLinkKind(l.URL)
+		}
+		if !validLinkKind(l.Kind) {
+			return nil, fmt.Errorf("%w: unknown kind %q for %s (expected one of %s)", ErrInvalidLink, l.Kind, l.URL, strings.Join(model.LinkKinds, ", "))
+		}
+
+		if i, ok := index[l.URL]; ok {
+			if normalized[i].Label == "" {
+				normalized[i].Label = l.Label
+			}
+			continue
+		}
+		index[l.URL] = len(normalized)
+		normalized = append(normalized, l)
+	}
+	return normalized, nil
+}
+
+// normalizeLinkURL lower-cases the scheme and host of an absolute http(s) URL.
+func normalizeLinkURL(raw string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", false
+	}
+	u.Host = strings.ToLower(u.Host)
+	return u.String(), true
+}
+
+func validLinkKind(kind string) bool {
+	for _, k := range model.LinkKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// FindTasksByRef returns the tasks, archived ones included, that link to ref or
+// were imported from it: ref is a URL or an external reference such as
+// "github:owner/repo#12".
+func FindTasksByRef(ref string) ([]model.Task, error) {
+	ref = strings.TrimSpace(ref)
+	needle, isURL := normalizeLinkURL(ref)
+	if !isURL {
+		needle = ref
+	}
+
+	// LIKE narrows the candidates; links are compared exactly below
+	var candidates []model.Task
+	if err := DB.Where("external_ref = ? OR links LIKE ?", ref, "%"+needle+"%").
+		Order("created_at asc, id asc").
+		Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	tasks := []model.Task{}
+	for _, t := range candidates {
+		match := t.ExternalRef == ref
+		for _, l := range t.Links {
+			if u, ok := normalizeLinkURL(l.URL); isURL && ok && u == needle {
+				match = true
+			}
+		}
+		if match {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks, nil
+}
@@ -0,0 +1,404 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrSchemaOutdated is returned when the database has pending migrations and
+// automatic migration is not enabled.
+var ErrSchemaOutdated = errors.New("database schema is out of date")
+
+// Migration is one versioned schema change. Migrations are applied in Version order,
+// each in its own transaction together with its schema_migrations row.
+//
+// A migration is written either in Go (Up / Down) or as plain SQL statements
+// (UpSQL / DownSQL), executed one at a time so no driver needs multi-statement
+// support. SQL migrations must stick to syntax all supported backends accept.
+//
+// Migrations must not use the structs in internal/model: those follow the latest
+// schema, while a migration has to keep producing the schema of its own version.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+	UpSQL   []string
+	DownSQL []string
+}
+
+// up applies the migration inside tx.
+func (m Migration) up(tx *gorm.DB) error {
+	if m.Up != nil {
+		return m.Up(tx)
+	}
+	return execSQL(tx, m.UpSQL)
+}
+
+// down reverts the migration inside tx.
+func (m Migration) down(tx *gorm.DB) error {
+	if m.Down != nil {
+		return m.Down(tx)
+	}
+	return execSQL(tx, m.DownSQL)
+}
+
+func execSQL(tx *gorm.DB, statements []string) error {
+	for _, stmt := range statements {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SchemaMigration is a row of the schema_migrations table.
+type SchemaMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false" json:"version"`
+	Name      string    `gorm:"type:varchar(255);not null" json:"name"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+// MigrationState describes whether a known migration has been applied.
+type MigrationState struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// migrations lists every schema change, oldest first. Append new migrations with the
+// next version number; never edit or reorder ones that have been released.
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create tasks and task_logs",
+		// AutoMigrate on the frozen v1 structs also adopts databases created before
+		// versioned migrations existed, since their tables already match.
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&v1Task{}, &v1TaskLog{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&v1TaskLog{}, &v1Task{})
+		},
+	},
+	{
+		Version: 2,
+		Name:    "create task_status_changes",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&v2TaskStatusChange{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&v2TaskStatusChange{})
+		},
+	},
+	{
+		Version: 3,
+		Name:    "add tasks.external_ref",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&v3Task{}, "ExternalRef"); err != nil {
+				return err
+			}
+			return tx.Migrator().CreateIndex(&v3Task{}, "ExternalRef")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropIndex(&v3Task{}, "ExternalRef"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&v3Task{}, "ExternalRef")
+		},
+	},
+	{
+		Version: 4,
+		Name:    "add tasks.short_id",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&v4Task{}, "ShortID"); err != nil {
+				return err
+			}
+			// Number existing tasks in creation order before the unique index exists
+			var ids []string
+			if err := tx.Table("tasks").Order("created_at asc, id asc").Pluck("id", &ids).Error; err != nil {
+				return err
+			}
+			for i, id := range ids {
+				if err := tx.Table("tasks").Where("id = ?", id).Update("short_id", i+1).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().CreateIndex(&v4Task{}, "ShortID")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropIndex(&v4Task{}, "ShortID"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&v4Task{}, "ShortID")
+		},
+	},
+	{
+		Version: 5,
+		Name:    "create counters",
+		// Short ids come from a counter from now on, so deleted ones are not reused
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&v5Counter{}); err != nil {
+				return err
+			}
+			var n int
+			if err := tx.Table("tasks").Select("COALESCE(MAX(short_id), 0)").Scan(&n).Error; err != nil {
+				return err
+			}
+			return tx.Create(&v5Counter{Name: "task_short_id", Value: n}).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&v5Counter{})
+		},
+	},
+	{
+		Version: 6,
+		Name:    "add tasks.tags and tasks.parent_id",
+		Up: func(tx *gorm.DB) error {
+			for _, field := range []string{"Tags", "ParentID"} {
+				if err := tx.Migrator().AddColumn(&v6Task{}, field); err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().CreateIndex(&v6Task{}, "ParentID")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropIndex(&v6Task{}, "ParentID"); err != nil {
+				return err
+			}
+			for _, field := range []string{"ParentID", "Tags"} {
+				if err := tx.Migrator().DropColumn(&v6Task{}, field); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+}
+
+// LatestSchemaVersion returns the newest schema version this binary knows about.
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// SchemaVersion returns the newest migration version applied to the database.
+func SchemaVersion(db *gorm.DB) (int, error) {
+	var version int
+	err := db.Model(&SchemaMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	return version, err
+}
+
+// MigrationStatus lists all known migrations and when each was applied.
+func MigrationStatus(db *gorm.DB) ([]MigrationState, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]MigrationState, 0, len(migrations))
+	for _, m := range migrations {
+		state := MigrationState{Version: m.Version, Name: m.Name}
+		if row, ok := applied[m.Version]; ok {
+			at := row.AppliedAt
+			state.AppliedAt = &at
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// MigrateUp applies pending migrations up to and including target (0 means all)
+// and returns the ones it applied.
+func MigrateUp(db *gorm.DB, target int) ([]Migration, error) {
+	if err := checkSchemaVersion(db); err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range migrations {
+		if target > 0 && m.Version > target {
+			break
+		}
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// MigrateDown reverts the given number of most recently applied migrations and
+// returns the ones it reverted.
+func MigrateDown(db *gorm.DB, steps int) ([]Migration, error) {
+	if err := checkSchemaVersion(db); err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, m.Version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("revert migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// PendingMigrations returns the known migrations not yet applied to the database.
+func PendingMigrations(db *gorm.DB) ([]Migration, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; !ok {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// prepareSchema brings a freshly opened database up to date. An empty database is
+// always initialised; an existing one is only migrated when autoMigrate is set,
+// otherwise ErrSchemaOutdated asks the user to run `chronicle migrate up`.
+func prepareSchema(db *gorm.DB, autoMigrate bool) ([]Migration, error) {
+	if err := checkSchemaVersion(db); err != nil {
+		return nil, err
+	}
+	pending, err := PendingMigrations(db)
+	if err != nil || len(pending) == 0 {
+		return nil, err
+	}
+
+	if !autoMigrate && db.Migrator().HasTable("tasks") {
+		version, err := SchemaVersion(db)
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: version %d, this chronicle needs %d; back it up and run `chronicle migrate up`", ErrSchemaOutdated, version, LatestSchemaVersion())
+	}
+	return MigrateUp(db, 0)
+}
+
+// checkSchemaVersion refuses to touch a database migrated by a newer binary.
+func checkSchemaVersion(db *gorm.DB) error {
+	version, err := SchemaVersion(db)
+	if err != nil {
+		return err
+	}
+	if latest := LatestSchemaVersion(); version > latest {
+		return fmt.Errorf("database schema version %d is newer than this binary supports (%d); upgrade chronicle", version, latest)
+	}
+	return nil
+}
+
+func appliedMigrations(db *gorm.DB) (map[int]SchemaMigration, error) {
+	var rows []SchemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int]SchemaMigration, len(rows))
+	for _, r := range rows {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+// Frozen table definitions used by the migrations above.
+
+type v1Task struct {
+	ID                string `gorm:"type:varchar(36);primaryKey"`
+	Title             string `gorm:"type:varchar(255);not null"`
+	Category          string `gorm:"type:varchar(100);not null"`
+	Description       string `gorm:"type:text"`
+	Targets           string `gorm:"type:text"`
+	Links             string `gorm:"type:text"`
+	Status            string `gorm:"type:varchar(20);default:'todo';not null"`
+	Deadline          *time.Time
+	ActualCompletedAt *time.Time
+	ArchivedAt        *time.Time `gorm:"index"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+func (v1Task) TableName() string { return "tasks" }
+
+type v1TaskLog struct {
+	ID           string `gorm:"type:varchar(36);primaryKey"`
+	TaskID       string `gorm:"type:varchar(36);index;not null"`
+	LogText      string `gorm:"type:text;not null"`
+	ProgressNote string `gorm:"type:varchar(100)"`
+	CreatedAt    time.Time
+}
+
+func (v1TaskLog) TableName() string { return "task_logs" }
+
+type v2TaskStatusChange struct {
+	ID         string    `gorm:"type:varchar(36);primaryKey"`
+	TaskID     string    `gorm:"type:varchar(36);index;not null"`
+	FromStatus string    `gorm:"type:varchar(20)"`
+	ToStatus   string    `gorm:"type:varchar(20);not null"`
+	CreatedAt  time.Time `gorm:"index"`
+}
+
+func (v2TaskStatusChange) TableName() string { return "task_status_changes" }
+
+// v3Task holds only the column added by migration 3.
+type v3Task struct {
+	ExternalRef string `gorm:"type:varchar(255);index"`
+}
+
+func (v3Task) TableName() string { return "tasks" }
+
+// v4Task holds only the column added by migration 4.
+type v4Task struct {
+	ShortID int `gorm:"uniqueIndex"`
+}
+
+func (v4Task) TableName() string { return "tasks" }
+
+type v5Counter struct {
+	Name  string `gorm:"type:varchar(64);primaryKey"`
+	Value int    `gorm:"not null"`
+}
+
+func (v5Counter) TableName() string { return "counters" }
+
+// v6Task holds only the columns added by migration 6.
+type v6Task struct {
+	Tags     string `gorm:"type:varchar(255)"`
+	ParentID string `gorm:"type:varchar(36);index"`
+}
+
+func (v6Task) TableName() string { return "tasks" }
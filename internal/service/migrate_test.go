@@ -0,0 +1,73 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPrepareSchemaInitialisesEmptyDatabase(t *testing.T) {
+	db := openTestDB(t)
+
+	applied, err := prepareSchema(db, false)
+	if err != nil {
+		t.Fatalf("prepareSchema: %v", err)
+	}
+	if len(applied) != len(migrations) {
+		t.Errorf("applied %d migrations, want %d", len(applied), len(migrations))
+	}
+	if version, _ := SchemaVersion(db); version != LatestSchemaVersion() {
+		t.Errorf("schema version = %d, want %d", version, LatestSchemaVersion())
+	}
+}
+
+func TestPrepareSchemaRefusesPendingMigrations(t *testing.T) {
+	db := openTestDB(t)
+	if _, err := MigrateUp(db, LatestSchemaVersion()-1); err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+
+	if _, err := prepareSchema(db, false); !errors.Is(err, ErrSchemaOutdated) {
+		t.Fatalf("prepareSchema without auto-migrate = %v, want ErrSchemaOutdated", err)
+	}
+	if version, _ := SchemaVersion(db); version != LatestSchemaVersion()-1 {
+		t.Errorf("schema version changed to %d without auto-migrate", version)
+	}
+
+	applied, err := prepareSchema(db, true)
+	if err != nil {
+		t.Fatalf("prepareSchema with auto-migrate: %v", err)
+	}
+	if len(applied) != 1 || applied[0].Version != LatestSchemaVersion() {
+		t.Errorf("applied %v, want only version %d", applied, LatestSchemaVersion())
+	}
+}
+
+func TestSQLMigration(t *testing.T) {
+	db := openTestDB(t)
+
+	prev := migrations
+	migrations = append(append([]Migration{}, prev...), Migration{
+		Version: LatestSchemaVersion() + 1,
+		Name:    "create notes",
+		UpSQL: []string{
+			"CREATE TABLE notes (id VARCHAR(36) PRIMARY KEY, body VARCHAR(255))",
+			"CREATE INDEX idx_notes_body ON notes (body)",
+		},
+		DownSQL: []string{"DROP TABLE notes"},
+	})
+	t.Cleanup(func() { migrations = prev })
+
+	if _, err := MigrateUp(db, 0); err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+	if !db.Migrator().HasTable("notes") || !db.Migrator().HasIndex("notes", "idx_notes_body") {
+		t.Fatal("SQL migration did not create notes and its index")
+	}
+
+	if _, err := MigrateDown(db, 1); err != nil {
+		t.Fatalf("MigrateDown: %v", err)
+	}
+	if db.Migrator().HasTable("notes") {
+		t.Error("SQL down migration did not drop notes")
+	}
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/yuyudeqiu/chronicle/internal/model"
+)
+
+const (
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// PeriodRange returns the YYYY-MM-DD bounds of the calendar week (Monday to Sunday)
+// or month containing anchor.
+func PeriodRange(period string, anchor time.Time) (string, string, error) {
+	day := time.Date(anchor.Year(), anchor.Month(), anchor.Day(), 0, 0, 0, 0, anchor.Location())
+
+	var from, to time.Time
+	switch period {
+	case PeriodWeek:
+		from = startOfWeek(day)
+		to = from.AddDate(0, 0, 6)
+	case PeriodMonth:
+		from = time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+		to = from.AddDate(0, 1, -1)
+	default:
+		return "", "", fmt.Errorf("unsupported period: %s", period)
+	}
+
+	return from.Format("2006-01-02"), to.Format("2006-01-02"), nil
+}
+
+// GetPeriodSummary aggregates worklogs between from and to (inclusive, YYYY-MM-DD,
+// defaulting to the last 7 days) by category and task, and lists the tasks that were
+// completed, started or slipped past their deadline in that period. Days are
+// delimited in loc.
+func GetPeriodSummary(fromStr, toStr string, loc *time.Location) (*model.PeriodSummaryResp, error) {
+	from, to, err := parseDateRange(fromStr, toStr, 7, loc)
+	if err != nil {
+		return nil, err
+	}
+	end := to.AddDate(0, 0, 1)
+
+	var logs []model.TaskLog
+	if err := DB.Where("created_at >= ? AND created_at < ?", dbTime(from), dbTime(end)).
+		Order("created_at asc").
+		Find(&logs).Error; err != nil {
+		return nil, err
+	}
+
+	taskLogMap := make(map[string][]string)
+	var taskIDs []string
+	for _, l := range logs {
+		if len(taskLogMap[l.TaskID]) == 0 {
+			taskIDs = append(taskIDs, l.TaskID)
+		}
+		taskLogMap[l.TaskID] = append(taskLogMap[l.TaskID], l.CreatedAt.In(loc).Format("01-02 15:04")+" - "+l.LogText)
+	}
+
+	taskMap := make(map[string]model.Task)
+	if len(taskIDs) > 0 {
+		var tasks []model.Task
+		if err := DB.Where("id IN ?", taskIDs).Find(&tasks).Error; err != nil {
+			return nil, err
+		}
+		for _, t := range tasks {
+			taskMap[t.ID] = t
+		}
+	}
+
+	var categoryNames []string
+	byCategory := make(map[string][]model.PeriodTaskActivity)
+	for _, tid := range taskIDs {
+		t, ok := taskMap[tid]
+		if !ok {
+			continue
+		}
+		if _, seen := byCategory[t.Category]; !seen {
+			categoryNames = append(categoryNames, t.Category)
+		}
+		byCategory[t.Category] = append(byCategory[t.Category], model.PeriodTaskActivity{
+			TaskID:    t.ID,
+			TaskTitle: t.Title,
+			Status:    t.Status,
+			Logs:      taskLogMap[tid],
+		})
+	}
+	sort.Strings(categoryNames)
+
+	categories := []model.PeriodCategoryActivity{}
+	for _, name := range categoryNames {
+		categories = append(categories, model.PeriodCategoryActivity{
+			Category: name,
+			Tasks:    byCategory[name],
+		})
+	}
+
+	completed, err := completedInPeriod(from, end, loc)
+	if err != nil {
+		return nil, err
+	}
+	started, err := startedInPeriod(from, end, loc)
+	if err != nil {
+		return nil, err
+	}
+	slipped, err := slippedInPeriod(from, end, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &model.PeriodSummaryResp{
+		From:       from.Format("2006-01-02"),
+		To:         to.Format("2006-01-02"),
+		TotalLogs:  len(logs),
+		Categories: categories,
+		Completed:  completed,
+		Started:    started,
+		Slipped:    slipped,
+	}
+
+	return resp, nil
+}
+
+func completedInPeriod(from, end time.Time, loc *time.Location) ([]model.PeriodTaskRef, error) {
+	var tasks []model.Task
+	if err := DB.Where("status = ? AND actual_completed_at >= ? AND actual_completed_at < ?", model.TaskStatusDone, dbTime(from), dbTime(end)).
+		Order("actual_completed_at asc").
+		Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+
+	refs := []model.PeriodTaskRef{}
+	for _, t := range tasks {
+		refs = append(refs, taskRef(t, *t.ActualCompletedAt, loc))
+	}
+	return refs, nil
+}
+
+// startedInPeriod lists tasks whose first move to in-progress happened in the period.
+func startedInPeriod(from, end time.Time, loc *time.Location) ([]model.PeriodTaskRef, error) {
+	var changes []model.TaskStatusChange
+	if err := DB.Where("to_status = ? AND created_at < ?", model.TaskStatusInProgress, dbTime(end)).
+		Order("created_at asc").
+		Find(&changes).Error; err != nil {
+		return nil, err
+	}
+
+	firstStart := make(map[string]time.Time)
+	var ids []string
+	for _, c := range changes {
+		if _, ok := firstStart[c.TaskID]; ok {
+			continue
+		}
+		firstStart[c.TaskID] = c.CreatedAt
+		if !c.CreatedAt.Before(from) {
+			ids = append(ids, c.TaskID)
+		}
+	}
+
+	refs := []model.PeriodTaskRef{}
+	if len(ids) == 0 {
+		return refs, nil
+	}
+
+	var tasks []model.Task
+	if err := DB.Where("id IN ?", ids).Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	for _, t := range tasks {
+		refs = append(refs, taskRef(t, firstStart[t.ID], loc))
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].At < refs[j].At })
+	return refs, nil
+}
+
+// slippedInPeriod lists tasks whose deadline fell in the period and which were either
+// completed after the deadline or are still open once the deadline has passed.
+func slippedInPeriod(from, end time.Time, loc *time.Location) ([]model.PeriodTaskRef, error) {
+	var tasks []model.Task
+	if err := DB.Where("deadline >= ? AND deadline < ?", dbTime(from), dbTime(end)).
+		Order("deadline asc").
+		Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	refs := []model.PeriodTaskRef{}
+	for _, t := range tasks {
+		deadline := *t.Deadline
+		if t.ActualCompletedAt != nil {
+			if t.ActualCompletedAt.After(deadline) {
+				refs = append(refs, taskRef(t, deadline, loc))
+			}
+			continue
+		}
+		if deadline.Before(now) {
+			refs = append(refs, taskRef(t, deadline, loc))
+		}
+	}
+	return refs, nil
+}
+
+func taskRef(t model.Task, at time.Time, loc *time.Location) model.PeriodTaskRef {
+	return model.PeriodTaskRef{
+		TaskID:    t.ID,
+		TaskTitle: t.Title,
+		Category:  t.Category,
+		At:        at.In(loc).Format("2006-01-02 15:04"),
+	}
+}
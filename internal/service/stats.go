@@ -0,0 +1,419 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/yuyudeqiu/chronicle/internal/model"
+)
+
+// GetFlowStats computes lead time (created -> done), cycle time (first in-progress -> done)
+// and weekly throughput for tasks completed within the last `weeks` weeks.
+// An empty category means all categories; weeks are bucketed in loc.
+func GetFlowStats(weeks int, category string, loc *time.Location) (*model.FlowStatsResp, error) {
+	if weeks <= 0 {
+		weeks = 8
+	}
+
+	now := time.Now().In(loc)
+	since := startOfWeek(now).AddDate(0, 0, -7*(weeks-1))
+
+	var tasks []model.Task
+	query := DB.Where("status = ? AND actual_completed_at IS NOT NULL AND actual_completed_at >= ?", model.TaskStatusDone, dbTime(since))
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+	if err := query.Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+
+	startedAt, err := firstStartedAt(tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	var leadHours, cycleHours []float64
+	catLead := make(map[string][]float64)
+	catCycle := make(map[string][]float64)
+	weekCounts := make(map[string]int)
+
+	for _, t := range tasks {
+		done := t.ActualCompletedAt.In(loc)
+
+		lead := done.Sub(t.CreatedAt).Hours()
+		leadHours = append(leadHours, lead)
+		catLead[t.Category] = append(catLead[t.Category], lead)
+
+		// Tasks created before status history existed have no in-progress record
+		if start, ok := startedAt[t.ID]; ok && !start.After(done) {
+			cycle := done.Sub(start).Hours()
+			cycleHours = append(cycleHours, cycle)
+			catCycle[t.Category] = append(catCycle[t.Category], cycle)
+		}
+
+		weekCounts[startOfWeek(done).Format("2006-01-02")]++
+	}
+
+	byCategory := make(map[string]model.CategoryFlowStats)
+	for cat, hours := range catLead {
+		byCategory[cat] = model.CategoryFlowStats{
+			Completed: len(hours),
+			LeadTime:  summariseDurations(hours),
+			CycleTime: summariseDurations(catCycle[cat]),
+		}
+	}
+
+	var throughput []model.WeeklyThroughput
+	for i := 0; i < weeks; i++ {
+		weekStart := since.AddDate(0, 0, 7*i).Format("2006-01-02")
+		throughput = append(throughput, model.WeeklyThroughput{
+			WeekStart: weekStart,
+			Completed: weekCounts[weekStart],
+		})
+	}
+
+	resp := &model.FlowStatsResp{
+		Since:      since.Format("2006-01-02"),
+		LeadTime:   summariseDurations(leadHours),
+		CycleTime:  summariseDurations(cycleHours),
+		ByCategory: byCategory,
+		Throughput: throughput,
+	}
+
+	return resp, nil
+}
+
+// firstStartedAt returns, per task ID, the first time the task moved to in-progress.
+func firstStartedAt(tasks []model.Task) (map[string]time.Time, error) {
+	startedAt := make(map[string]time.Time)
+	if len(tasks) == 0 {
+		return startedAt, nil
+	}
+
+	var ids []string
+	for _, t := range tasks {
+		ids = append(ids, t.ID)
+	}
+
+	var changes []model.TaskStatusChange
+	if err := DB.Where("task_id IN ? AND to_status = ?", ids, model.TaskStatusInProgress).
+		Order("created_at asc").
+		Find(&changes).Error; err != nil {
+		return nil, err
+	}
+
+	for _, c := range changes {
+		if _, ok := startedAt[c.TaskID]; !ok {
+			startedAt[c.TaskID] = c.CreatedAt
+		}
+	}
+	return startedAt, nil
+}
+
+func summariseDurations(hours []float64) model.DurationStats {
+	if len(hours) == 0 {
+		return model.DurationStats{}
+	}
+
+	sorted := append([]float64(nil), hours...)
+	sort.Float64s(sorted)
+
+	var sum float64
+	for _, h := range sorted {
+		sum += h
+	}
+
+	return model.DurationStats{
+		Count:    len(sorted),
+		AvgHours: roundHours(sum / float64(len(sorted))),
+		P50Hours: roundHours(percentile(sorted, 50)),
+		P85Hours: roundHours(percentile(sorted, 85)),
+		P95Hours: roundHours(percentile(sorted, 95)),
+	}
+}
+
+// percentile uses the nearest-rank method on an ascending slice.
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func roundHours(h float64) float64 {
+	return math.Round(h*10) / 10
+}
+
+// taskTimeline is a task together with its recorded status history, used to
+// reconstruct the status of the task at any past moment.
+type taskTimeline struct {
+	task    model.Task
+	changes []model.TaskStatusChange
+}
+
+// statusAt returns the status the task had at t, or "" if it did not exist yet.
+func (tl taskTimeline) statusAt(t time.Time) string {
+	if tl.task.CreatedAt.After(t) {
+		return ""
+	}
+
+	status := ""
+	for _, c := range tl.changes {
+		if c.CreatedAt.After(t) {
+			break
+		}
+		status = c.ToStatus
+	}
+	if status != "" {
+		return status
+	}
+
+	// Tasks created before status history existed: fall back to the timestamps we have
+	if tl.task.ActualCompletedAt != nil && !tl.task.ActualCompletedAt.After(t) {
+		return model.TaskStatusDone
+	}
+	if tl.task.Status == model.TaskStatusInProgress {
+		return model.TaskStatusInProgress
+	}
+	return model.TaskStatusTodo
+}
+
+// SeriesFilter selects the tasks counted by GetCumulativeFlow and GetBurndown. Empty
+// fields do not filter.
+type SeriesFilter struct {
+	Category string
+	// Tag keeps tasks carrying this tag.
+	Tag string
+	// Parent keeps the subtasks of this task, at any depth, but not the task itself.
+	// It accepts anything ResolveTaskID does.
+	Parent string
+}
+
+func loadTaskTimelines(filter SeriesFilter) ([]taskTimeline, error) {
+	var tasks []model.Task
+	query := DB.Model(&model.Task{})
+	if filter.Category != "" {
+		query = query.Where("category = ?", filter.Category)
+	}
+	if filter.Parent != "" {
+		parentID, err := ResolveTaskID(filter.Parent)
+		if err != nil {
+			return nil, err
+		}
+		subtasks, err := subtaskIDs(parentID)
+		if err != nil {
+			return nil, err
+		}
+		if len(subtasks) == 0 {
+			return nil, nil
+		}
+		query = query.Where("id IN ?", subtasks)
+	}
+	if err := query.Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	if tag := NormalizeTags(filter.Tag); tag != "" {
+		kept := tasks[:0]
+		for _, t := range tasks {
+			if hasTag(t.Tags, tag) {
+				kept = append(kept, t)
+			}
+		}
+		tasks = kept
+	}
+	if len(tasks) == 0 {
+		return nil, nil
+	}
+
+	var ids []string
+	for _, t := range tasks {
+		ids = append(ids, t.ID)
+	}
+
+	var changes []model.TaskStatusChange
+	if err := DB.Where("task_id IN ?", ids).Order("created_at asc").Find(&changes).Error; err != nil {
+		return nil, err
+	}
+
+	changesByTask := make(map[string][]model.TaskStatusChange)
+	for _, c := range changes {
+		changesByTask[c.TaskID] = append(changesByTask[c.TaskID], c)
+	}
+
+	timelines := make([]taskTimeline, 0, len(tasks))
+	for _, t := range tasks {
+		timelines = append(timelines, taskTimeline{task: t, changes: changesByTask[t.ID]})
+	}
+	return timelines, nil
+}
+
+// subtaskIDs returns the ids of all tasks below parentID, level by level.
+func subtaskIDs(parentID string) ([]string, error) {
+	var all []string
+	seen := map[string]bool{parentID: true}
+	for level := []string{parentID}; len(level) > 0; {
+		var children []string
+		if err := DB.Model(&model.Task{}).Where("parent_id IN ?", level).Pluck("id", &children).Error; err != nil {
+			return nil, err
+		}
+		level = level[:0]
+		for _, id := range children {
+			if !seen[id] {
+				seen[id] = true
+				level = append(level, id)
+			}
+		}
+		all = append(all, level...)
+	}
+	return all, nil
+}
+
+// GetCumulativeFlow returns, for every day in [from, to], how many tasks were in each
+// status at the end of that day. History is reconstructed from status changes, so
+// past ranges are supported. Days are delimited in loc.
+func GetCumulativeFlow(fromStr, toStr string, filter SeriesFilter, loc *time.Location) (*model.CFDResp, error) {
+	from, to, err := parseDateRange(fromStr, toStr, 14, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	timelines, err := loadTaskTimelines(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	points := []model.CFDPoint{}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		endOfDay := day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		point := model.CFDPoint{Date: day.Format("2006-01-02")}
+		for _, tl := range timelines {
+			switch tl.statusAt(endOfDay) {
+			case model.TaskStatusTodo:
+				point.Todo++
+			case model.TaskStatusInProgress:
+				point.InProgress++
+			case model.TaskStatusDone:
+				point.Done++
+			}
+		}
+		points = append(points, point)
+	}
+
+	resp := &model.CFDResp{
+		From:     from.Format("2006-01-02"),
+		To:       to.Format("2006-01-02"),
+		Category: filter.Category,
+		Tag:      NormalizeTags(filter.Tag),
+		Parent:   filter.Parent,
+		Points:   points,
+	}
+
+	return resp, nil
+}
+
+// GetBurndown returns the remaining (not done) work per day for the selected tasks,
+// along with the total scope and an ideal straight line from the first day's
+// remaining count down to zero on the last day.
+func GetBurndown(fromStr, toStr string, filter SeriesFilter, loc *time.Location) (*model.BurndownResp, error) {
+	cfd, err := GetCumulativeFlow(fromStr, toStr, filter, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	points := []model.BurndownPoint{}
+	if len(cfd.Points) > 0 {
+		first := cfd.Points[0]
+		initial := float64(first.Todo + first.InProgress)
+		steps := float64(len(cfd.Points) - 1)
+
+		for i, p := range cfd.Points {
+			ideal := 0.0
+			if steps > 0 {
+				ideal = math.Round(initial*(1-float64(i)/steps)*10) / 10
+			}
+			points = append(points, model.BurndownPoint{
+				Date:      p.Date,
+				Scope:     p.Todo + p.InProgress + p.Done,
+				Remaining: p.Todo + p.InProgress,
+				Ideal:     ideal,
+			})
+		}
+	}
+
+	resp := &model.BurndownResp{
+		From:     cfd.From,
+		To:       cfd.To,
+		Category: cfd.Category,
+		Tag:      cfd.Tag,
+		Parent:   cfd.Parent,
+		Points:   points,
+	}
+
+	return resp, nil
+}
+
+// GetWorklogHeatmap returns per-day worklog counts for the last `days` days (today
+// included), plus the current and longest streaks of consecutive logging days.
+// The current streak still counts if nothing has been logged yet today. Days are
+// delimited in loc.
+func GetWorklogHeatmap(days int, loc *time.Location) (*model.HeatmapResp, error) {
+	if days <= 0 {
+		days = 365
+	}
+	if days > maxSeriesDays {
+		days = maxSeriesDays
+	}
+
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	from := today.AddDate(0, 0, -(days - 1))
+
+	var logs []model.TaskLog
+	if err := DB.Select("created_at").
+		Where("created_at >= ?", dbTime(from)).
+		Find(&logs).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, l := range logs {
+		counts[l.CreatedAt.In(loc).Format("2006-01-02")]++
+	}
+
+	resp := &model.HeatmapResp{
+		From:      from.Format("2006-01-02"),
+		To:        today.Format("2006-01-02"),
+		TotalLogs: len(logs),
+	}
+
+	run := 0
+	for day := from; !day.After(today); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		count := counts[date]
+		resp.Days = append(resp.Days, model.HeatmapDay{Date: date, Count: count})
+
+		if count > 0 {
+			resp.ActiveDays++
+			run++
+			if run > resp.LongestStreak {
+				resp.LongestStreak = run
+			}
+		} else {
+			run = 0
+		}
+	}
+
+	// Walk back from today (or yesterday, if today has no log yet)
+	day := today
+	if counts[day.Format("2006-01-02")] == 0 {
+		day = day.AddDate(0, 0, -1)
+	}
+	for !day.Before(from) && counts[day.Format("2006-01-02")] > 0 {
+		resp.CurrentStreak++
+		day = day.AddDate(0, 0, -1)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/yuyudeqiu/chronicle/internal/model"
+)
+
+func TestCumulativeFlowFilters(t *testing.T) {
+	setupTestDB(t)
+
+	epic := createTestTask(t, "release", "dev")
+	for _, req := range []model.CreateTaskReq{
+		{Title: "api", Category: "dev", Tags: "Backend, sprint-1", ParentID: epic.ID},
+		{Title: "ui", Category: "dev", Tags: "frontend sprint-1", ParentID: epic.ID},
+		{Title: "docs", Category: "writing", Tags: "sprint-2"},
+	} {
+		task, err := CreateTask(req)
+		if err != nil {
+			t.Fatalf("CreateTask(%s): %v", req.Title, err)
+		}
+		if req.Title == "api" {
+			if task.Tags != "backend,sprint-1" {
+				t.Errorf("tags = %q, want backend,sprint-1", task.Tags)
+			}
+			// A subtask of a subtask still counts towards the epic
+			if _, err := CreateTask(model.CreateTaskReq{Title: "api tests", Category: "dev", ParentID: "#" + strconv.Itoa(task.ShortID)}); err != nil {
+				t.Fatalf("CreateTask(api tests): %v", err)
+			}
+		}
+	}
+
+	today := time.Now().Format("2006-01-02")
+	total := func(filter SeriesFilter) int {
+		t.Helper()
+		cfd, err := GetCumulativeFlow(today, today, filter, time.Local)
+		if err != nil {
+			t.Fatalf("GetCumulativeFlow(%+v): %v", filter, err)
+		}
+		p := cfd.Points[0]
+		return p.Todo + p.InProgress + p.Done
+	}
+
+	for _, tc := range []struct {
+		filter SeriesFilter
+		want   int
+	}{
+		{SeriesFilter{}, 5},
+		{SeriesFilter{Category: "dev"}, 4},
+		{SeriesFilter{Tag: "sprint-1"}, 2},
+		{SeriesFilter{Tag: "#Sprint-2"}, 1},
+		{SeriesFilter{Parent: epic.ID}, 3},
+		{SeriesFilter{Parent: epic.ID, Tag: "frontend"}, 1},
+	} {
+		if got := total(tc.filter); got != tc.want {
+			t.Errorf("tasks for %+v = %d, want %d", tc.filter, got, tc.want)
+		}
+	}
+
+	if _, err := GetBurndown(today, today, SeriesFilter{Parent: "#99"}, time.Local); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("burndown for unknown parent: err = %v, want ErrTaskNotFound", err)
+	}
+}
+
+func TestUpdateTaskParent(t *testing.T) {
+	setupTestDB(t)
+
+	parent := createTestTask(t, "parent", "dev")
+	child, err := CreateTask(model.CreateTaskReq{Title: "child", Category: "dev", ParentID: parent.ID})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+
+	if _, err := UpdateTask(parent.ID, model.UpdateTaskReq{ParentID: &child.ID}); !errors.Is(err, ErrInvalidParent) {
+		t.Errorf("parent under its own child: err = %v, want ErrInvalidParent", err)
+	}
+	if _, err := UpdateTask(parent.ID, model.UpdateTaskReq{ParentID: &parent.ID}); !errors.Is(err, ErrInvalidParent) {
+		t.Errorf("task as its own parent: err = %v, want ErrInvalidParent", err)
+	}
+
+	if err := DeleteTask(parent.ID); err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+	got, err := GetTask(child.ID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if got.ParentID != "" {
+		t.Errorf("parent of orphaned subtask = %q, want none", got.ParentID)
+	}
+}
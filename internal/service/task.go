@@ -2,6 +2,9 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,6 +13,15 @@ import (
 )
 
 func CreateTask(req model.CreateTaskReq) (*model.Task, error) {
+	links, err := NormalizeLinks(req.Links)
+	if err != nil {
+		return nil, err
+	}
+	parentID, err := resolveParent("", req.ParentID)
+	if err != nil {
+		return nil, err
+	}
+
 	var localDeadline *time.Time
 	if req.Deadline != nil {
 		ld := req.Deadline.Local()
@@ -22,23 +34,62 @@ func CreateTask(req model.CreateTaskReq) (*model.Task, error) {
 		Category:    req.Category,
 		Description: req.Description,
 		Targets:     req.Targets,
+		Links:       links,
+		Tags:        NormalizeTags(req.Tags),
+		ParentID:    parentID,
 		Deadline:    localDeadline,
 		Status:      model.TaskStatusTodo,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 
-	if err := DB.Create(task).Error; err != nil {
+	// The counter row serialises concurrent creates; retrying covers the remaining
+	// races, such as two creates seeding a missing counter at once.
+	for attempt := 1; ; attempt++ {
+		err = DB.Transaction(func(tx *gorm.DB) error {
+			if task.ShortID, err = nextShortID(tx); err != nil {
+				return err
+			}
+			if err := tx.Create(task).Error; err != nil {
+				return err
+			}
+			return recordStatusChange(tx, task.ID, "", task.Status, task.CreatedAt)
+		})
+		if err == nil || !errors.Is(err, gorm.ErrDuplicatedKey) || attempt == maxCreateAttempts {
+			break
+		}
+	}
+	if err != nil {
 		return nil, err
 	}
 
 	return task, nil
 }
 
+// maxCreateAttempts bounds how often CreateTask retries after a short id conflict.
+const maxCreateAttempts = 5
+
+// recordStatusChange appends a status transition for a task. It is a no-op when
+// the status did not actually change, and must run inside the caller's transaction.
+func recordStatusChange(tx *gorm.DB, taskID, from, to string, at time.Time) error {
+	if from == to {
+		return nil
+	}
+	return tx.Create(&model.TaskStatusChange{
+		ID:         uuid.New().String(),
+		TaskID:     taskID,
+		FromStatus: from,
+		ToStatus:   to,
+		CreatedAt:  at,
+	}).Error
+}
+
 func GetActiveTasks() ([]model.ActiveTaskResp, error) {
 	var tasks []model.ActiveTaskResp
-	err := DB.Model(&model.Task{}).Select("id", "title", "category", "status", "deadline").
+	err := DB.Model(&model.Task{}).Select("id", "short_id", "title", "category", "status", "deadline").
 		Where("status IN ?", []string{model.TaskStatusTodo, model.TaskStatusInProgress}).
+		// Tasks without a deadline go last. Spelled as CASE because databases disagree on
+		// where NULLs sort and MySQL has no NULLS LAST.
 		Order("CASE WHEN deadline IS NULL THEN 1 ELSE 0 END, deadline ASC, created_at desc").
 		Find(&tasks).Error
 	if err != nil {
@@ -49,7 +100,7 @@ func GetActiveTasks() ([]model.ActiveTaskResp, error) {
 
 func GetHistoryTasks() ([]model.ActiveTaskResp, error) {
 	var tasks []model.ActiveTaskResp
-	err := DB.Model(&model.Task{}).Select("id", "title", "category", "status").
+	err := DB.Model(&model.Task{}).Select("id", "short_id", "title", "category", "status").
 		Where("status = ? AND archived_at IS NULL", model.TaskStatusDone).
 		Order("actual_completed_at desc").
 		Limit(50).
@@ -62,7 +113,7 @@ func GetHistoryTasks() ([]model.ActiveTaskResp, error) {
 
 func GetArchivedTasks() ([]model.ActiveTaskResp, error) {
 	var tasks []model.ActiveTaskResp
-	err := DB.Model(&model.Task{}).Select("id", "title", "category", "status").
+	err := DB.Model(&model.Task{}).Select("id", "short_id", "title", "category", "status").
 		Where("archived_at IS NOT NULL").
 		Order("archived_at desc").
 		Find(&tasks).Error
@@ -92,6 +143,13 @@ func DeleteTask(id string) error {
 		if err := tx.Where("task_id = ?", id).Delete(&model.TaskLog{}).Error; err != nil {
 			return err
 		}
+		if err := tx.Where("task_id = ?", id).Delete(&model.TaskStatusChange{}).Error; err != nil {
+			return err
+		}
+		// Subtasks outlive their parent as top-level tasks
+		if err := tx.Model(&model.Task{}).Where("parent_id = ?", id).Update("parent_id", "").Error; err != nil {
+			return err
+		}
 		// Delete task
 		if err := tx.Where("id = ?", id).Delete(&model.Task{}).Error; err != nil {
 			return err
@@ -136,8 +194,22 @@ func UpdateTask(id string, req model.UpdateTaskReq) (*model.Task, error) {
 	if req.Targets != "" {
 		updates["targets"] = req.Targets
 	}
-	if req.Links != "" {
-		updates["links"] = req.Links
+	if req.Links != nil {
+		links, err := NormalizeLinks(*req.Links)
+		if err != nil {
+			return nil, err
+		}
+		updates["links"] = links
+	}
+	if req.Tags != nil {
+		updates["tags"] = NormalizeTags(*req.Tags)
+	}
+	if req.ParentID != nil {
+		parentID, err := resolveParent(id, *req.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		updates["parent_id"] = parentID
 	}
 	if req.Deadline != nil {
 		updates["deadline"] = req.Deadline.Local()
@@ -149,7 +221,17 @@ func UpdateTask(id string, req model.UpdateTaskReq) (*model.Task, error) {
 		}
 	}
 
-	if err := DB.Model(&task).Updates(updates).Error; err != nil {
+	oldStatus := task.Status
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&task).Updates(updates).Error; err != nil {
+			return err
+		}
+		if req.Status != "" {
+			return recordStatusChange(tx, id, oldStatus, req.Status, time.Now())
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -197,7 +279,8 @@ func UpdateProgress(taskID string, req model.UpdateProgressReq) error {
 			newStatus = req.NewStatus
 		}
 
-		if newStatus != task.Status {
+		oldStatus := task.Status
+		if newStatus != oldStatus {
 			updates["status"] = newStatus
 		}
 
@@ -205,7 +288,7 @@ func UpdateProgress(taskID string, req model.UpdateProgressReq) error {
 			return err
 		}
 
-		return nil
+		return recordStatusChange(tx, taskID, oldStatus, newStatus, time.Now())
 	})
 }
 
@@ -214,25 +297,16 @@ func UpdateProgress(taskID string, req model.UpdateProgressReq) error {
 // returns what the agent did today.
 // In the design for GetDailySummary:
 // { "task_id": "xxx", "task_title": "...", "status": "done", "today_logs": ["10:12 - ...", ...] }
-func GetDailySummary(dateStr string) (*model.DailySummaryResp, error) {
-	// Parse date
-	var targetDate time.Time
-	var err error
-	if dateStr == "" {
-		targetDate = time.Now()
-	} else {
-		targetDate, err = time.Parse("2006-01-02", dateStr)
-		if err != nil {
-			return nil, err
-		}
+// The day boundaries and log times are taken in loc.
+func GetDailySummary(dateStr string, loc *time.Location) (*model.DailySummaryResp, error) {
+	startOfDay, endOfDay, err := DayBounds(dateStr, loc)
+	if err != nil {
+		return nil, err
 	}
 
-	startOfDay := time.Date(targetDate.Year(), targetDate.Month(), targetDate.Day(), 0, 0, 0, 0, targetDate.Location())
-	endOfDay := startOfDay.Add(24 * time.Hour).Add(-time.Nanosecond)
-
 	var logs []model.TaskLog
 	// Find all logs for the target date
-	if err := DB.Where("created_at >= ? AND created_at <= ?", startOfDay, endOfDay).
+	if err := DB.Where("created_at >= ? AND created_at < ?", dbTime(startOfDay), dbTime(endOfDay)).
 		Order("created_at asc").
 		Find(&logs).Error; err != nil {
 		return nil, err
@@ -241,7 +315,7 @@ func GetDailySummary(dateStr string) (*model.DailySummaryResp, error) {
 	taskLogMap := make(map[string][]string)
 	var taskIDs []string
 	for _, l := range logs {
-		timeStr := l.CreatedAt.Format("15:04")
+		timeStr := l.CreatedAt.In(loc).Format("15:04")
 		logLine := timeStr + " - " + l.LogText
 		if len(taskLogMap[l.TaskID]) == 0 {
 			taskIDs = append(taskIDs, l.TaskID)
@@ -284,7 +358,9 @@ func GetDailySummary(dateStr string) (*model.DailySummaryResp, error) {
 	return resp, nil
 }
 
-func GetStatsSummary() (*model.StatsSummaryResp, error) {
+// GetStatsSummary returns task counts and the last 7 days of activity, with days
+// delimited in loc.
+func GetStatsSummary(loc *time.Location) (*model.StatsSummaryResp, error) {
 	var totalTasks int64
 	var completedTasks int64
 	var todoTasks int64
@@ -317,16 +393,15 @@ func GetStatsSummary() (*model.StatsSummaryResp, error) {
 	// Weekly stats (last 7 days)
 	var weeklyStats []model.DailyStats
 	for i := 6; i >= 0; i-- {
-		date := time.Now().AddDate(0, 0, -i)
+		date := time.Now().In(loc).AddDate(0, 0, -i)
 		dateStr := date.Format("2006-01-02")
-		startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-		endOfDay := startOfDay.Add(24 * time.Hour)
+		startOfDay, endOfDay, _ := DayBounds(dateStr, loc)
 
 		var created int64
 		var completed int64
 
-		DB.Model(&model.Task{}).Where("created_at >= ? AND created_at < ?", startOfDay, endOfDay).Count(&created)
-		DB.Model(&model.Task{}).Where("status = ? AND actual_completed_at >= ? AND actual_completed_at < ?", model.TaskStatusDone, startOfDay, endOfDay).Count(&completed)
+		DB.Model(&model.Task{}).Where("created_at >= ? AND created_at < ?", dbTime(startOfDay), dbTime(endOfDay)).Count(&created)
+		DB.Model(&model.Task{}).Where("status = ? AND actual_completed_at >= ? AND actual_completed_at < ?", model.TaskStatusDone, dbTime(startOfDay), dbTime(endOfDay)).Count(&completed)
 
 		weeklyStats = append(weeklyStats, model.DailyStats{
 			Date:      dateStr,
@@ -347,3 +422,271 @@ func GetStatsSummary() (*model.StatsSummaryResp, error) {
 
 	return resp, nil
 }
+
+// FindTasks returns full tasks (without logs) filtered like the list API: by status
+// (empty means all), category, and whether archived tasks are included.
+func FindTasks(statuses []string, category string, includeArchived bool) ([]model.Task, error) {
+	query := DB.Model(&model.Task{})
+	if len(statuses) > 0 {
+		query = query.Where("status IN ?", statuses)
+	}
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+	if !includeArchived {
+		query = query.Where("archived_at IS NULL")
+	}
+
+	var tasks []model.Task
+	if err := query.Order("created_at asc, id asc").Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// GetTasksByID returns the tasks with the given IDs, keyed by ID. Unknown IDs are
+// left out.
+func GetTasksByID(ids []string) (map[string]model.Task, error) {
+	tasks := make(map[string]model.Task, len(ids))
+	if len(ids) == 0 {
+		return tasks, nil
+	}
+
+	var found []model.Task
+	if err := DB.Where("id IN ?", ids).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	for _, t := range found {
+		tasks[t.ID] = t
+	}
+	return tasks, nil
+}
+
+// GetWorklogsByID returns the worklogs with the given IDs, keyed by ID. Unknown IDs
+// are left out.
+func GetWorklogsByID(ids []string) (map[string]model.TaskLog, error) {
+	logs := make(map[string]model.TaskLog, len(ids))
+	if len(ids) == 0 {
+		return logs, nil
+	}
+
+	var found []model.TaskLog
+	if err := DB.Where("id IN ?", ids).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	for _, l := range found {
+		logs[l.ID] = l
+	}
+	return logs, nil
+}
+
+// ErrInvalidParent is returned when a task's parent does not exist, or would make
+// the task its own ancestor.
+var ErrInvalidParent = errors.New("invalid parent task")
+
+// resolveParent resolves ref to the id of the parent for task id (empty for a new
+// task). An empty ref means no parent.
+func resolveParent(id, ref string) (string, error) {
+	if strings.TrimSpace(ref) == "" {
+		return "", nil
+	}
+	parentID, err := ResolveTaskID(ref)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidParent, err)
+	}
+	// Walk up from the new parent; reaching the task itself would form a cycle
+	for ancestor := parentID; ancestor != ""; {
+		if ancestor == id {
+			return "", fmt.Errorf("%w: %s is %s or one of its subtasks", ErrInvalidParent, ref, id)
+		}
+		var parents []string
+		if err := DB.Model(&model.Task{}).Where("id = ?", ancestor).Limit(1).Pluck("parent_id", &parents).Error; err != nil {
+			return "", err
+		}
+		if len(parents) == 0 {
+			break
+		}
+		ancestor = parents[0]
+	}
+	return parentID, nil
+}
+
+// NormalizeTags turns a comma- or space-separated tag list into the stored form:
+// lower-case, without duplicates, joined by commas in the order given.
+func NormalizeTags(tags string) string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, tag := range strings.FieldsFunc(strings.ToLower(tags), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	}) {
+		tag = strings.TrimPrefix(tag, "#")
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	return strings.Join(out, ",")
+}
+
+// hasTag reports whether the stored tag list tags contains tag.
+func hasTag(tags, tag string) bool {
+	for _, t := range strings.Split(tags, ",") {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// MinTaskIDPrefix is the shortest prefix of a task id ResolveTaskID accepts.
+const MinTaskIDPrefix = 4
+
+var (
+	// ErrTaskNotFound is returned by ResolveTaskID when no task has the id.
+	ErrTaskNotFound = errors.New("task not found")
+	// ErrAmbiguousTaskID is returned by ResolveTaskID when a prefix matches several
+	// tasks.
+	ErrAmbiguousTaskID = errors.New("ambiguous task id")
+)
+
+// ResolveTaskID returns the full id of the task id refers to: a short id such as
+// "#12" or "12", the full id, or a unique prefix of it (case-insensitive, at least
+// MinTaskIDPrefix characters). A number is taken as a short id first, and only
+// tried as a prefix if no task has that short id. Archived tasks are included.
+func ResolveTaskID(id string) (string, error) {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if n, ok := parseShortID(id); ok {
+		var ids []string
+		if err := DB.Model(&model.Task{}).Where("short_id = ?", n).Limit(1).Pluck("id", &ids).Error; err != nil {
+			return "", err
+		}
+		if len(ids) == 1 {
+			return ids[0], nil
+		}
+		if strings.HasPrefix(id, "#") || len(id) < MinTaskIDPrefix {
+			return "", fmt.Errorf("%w: %s", ErrTaskNotFound, id)
+		}
+	}
+	if len(id) < MinTaskIDPrefix {
+		return "", fmt.Errorf("%w: %q (use at least %d characters)", ErrTaskNotFound, id, MinTaskIDPrefix)
+	}
+	if strings.ContainsAny(id, `%_\`) {
+		return "", fmt.Errorf("%w: %s", ErrTaskNotFound, id)
+	}
+
+	var ids []string
+	if err := DB.Model(&model.Task{}).Where("id = ?", id).Limit(1).Pluck("id", &ids).Error; err != nil {
+		return "", err
+	}
+	if len(ids) == 1 {
+		return ids[0], nil
+	}
+	if err := DB.Model(&model.Task{}).Where("id LIKE ?", id+"%").Order("id").Limit(3).Pluck("id", &ids).Error; err != nil {
+		return "", err
+	}
+	switch len(ids) {
+	case 0:
+		return "", fmt.Errorf("%w: %s", ErrTaskNotFound, id)
+	case 1:
+		return ids[0], nil
+	case 2:
+		return "", fmt.Errorf("%w: %s matches %s (use more characters)", ErrAmbiguousTaskID, id, strings.Join(ids, " and "))
+	}
+	return "", fmt.Errorf("%w: %s matches %s and more (use more characters)", ErrAmbiguousTaskID, id, strings.Join(ids[:2], ", "))
+}
+
+// parseShortID reads a short id written as "#12" or "12".
+func parseShortID(id string) (int, bool) {
+	digits := strings.TrimPrefix(id, "#")
+	if digits == "" || len(digits) > 9 || strings.Trim(digits, "0123456789") != "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(digits)
+	return n, err == nil && n > 0
+}
+
+// shortIDCounter names the counters row holding the last short id handed out.
+const shortIDCounter = "task_short_id"
+
+// Counter is a row of the counters table: a named number that only ever grows.
+type Counter struct {
+	Name  string `gorm:"type:varchar(64);primaryKey"`
+	Value int    `gorm:"not null"`
+}
+
+// nextShortID hands out the short id for a new task. Numbers come from a counter
+// rather than the highest id in use, so the id of a deleted task is never given to
+// another one; numbers already taken (e.g. by imported tasks) are skipped. The
+// counter row stays locked until the caller's transaction ends, which keeps
+// concurrent creates from getting the same number.
+func nextShortID(tx *gorm.DB) (int, error) {
+	for {
+		res := tx.Model(&Counter{}).Where("name = ?", shortIDCounter).Update("value", gorm.Expr("value + 1"))
+		if res.Error != nil {
+			return 0, res.Error
+		}
+		if res.RowsAffected == 0 {
+			// Seed a missing counter from the tasks, as migration 5 does
+			var n int
+			if err := tx.Model(&model.Task{}).Select("COALESCE(MAX(short_id), 0)").Scan(&n).Error; err != nil {
+				return 0, err
+			}
+			return n + 1, tx.Create(&Counter{Name: shortIDCounter, Value: n + 1}).Error
+		}
+
+		var c Counter
+		if err := tx.First(&c, "name = ?", shortIDCounter).Error; err != nil {
+			return 0, err
+		}
+		var taken int64
+		if err := tx.Model(&model.Task{}).Where("short_id = ?", c.Value).Count(&taken).Error; err != nil {
+			return 0, err
+		}
+		if taken == 0 {
+			return c.Value, nil
+		}
+	}
+}
+
+// reserveShortID makes sure the counter never hands out n, for a task that keeps a
+// short id it was imported with.
+func reserveShortID(tx *gorm.DB, n int) error {
+	return tx.Model(&Counter{}).Where("name = ? AND value < ?", shortIDCounter, n).Update("value", n).Error
+}
+
+// GetTasksByExternalRef looks up tasks, archived ones included, by the external
+// reference they were imported with. References without a task are left out.
+func GetTasksByExternalRef(refs []string) (map[string]model.Task, error) {
+	tasks := make(map[string]model.Task, len(refs))
+	if len(refs) == 0 {
+		return tasks, nil
+	}
+
+	var found []model.Task
+	if err := DB.Where("external_ref IN ?", refs).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	for _, t := range found {
+		tasks[t.ExternalRef] = t
+	}
+	return tasks, nil
+}
+
+// FindWorklogs returns worklogs between from and to (inclusive, YYYY-MM-DD, default
+// the last 30 days), oldest first. Unlike chart ranges the span is not limited, so
+// exports can cover years. Days are delimited in loc.
+func FindWorklogs(fromStr, toStr string, loc *time.Location) ([]model.TaskLog, error) {
+	from, to, err := parseDateBounds(fromStr, toStr, 30, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	var logs []model.TaskLog
+	if err := DB.Where("created_at >= ? AND created_at < ?", dbTime(from), dbTime(to.AddDate(0, 0, 1))).
+		Order("created_at asc, id asc").
+		Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yuyudeqiu/chronicle/internal/model"
+)
+
+func TestTaskLifecycle(t *testing.T) {
+	setupTestDB(t)
+
+	task := createTestTask(t, "write tests", "dev")
+	if task.Status != model.TaskStatusTodo || task.ShortID != 1 {
+		t.Fatalf("new task = %s #%d, want todo #1", task.Status, task.ShortID)
+	}
+
+	if err := UpdateProgress(task.ID, model.UpdateProgressReq{LogText: "started", NewStatus: model.TaskStatusInProgress}); err != nil {
+		t.Fatalf("UpdateProgress to in-progress: %v", err)
+	}
+	if err := UpdateProgress(task.ID, model.UpdateProgressReq{LogText: "finished", MarkAsDone: true}); err != nil {
+		t.Fatalf("UpdateProgress to done: %v", err)
+	}
+	if err := UpdateProgress(task.ID, model.UpdateProgressReq{LogText: "again"}); err == nil {
+		t.Error("UpdateProgress accepted a log on a done task")
+	}
+
+	got, err := GetTask(task.ID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if got.Status != model.TaskStatusDone || got.ActualCompletedAt == nil {
+		t.Errorf("task = %s, completed at %v; want done with a completion time", got.Status, got.ActualCompletedAt)
+	}
+	if len(got.Logs) != 2 || got.Logs[0].LogText != "started" || got.Logs[1].LogText != "finished" {
+		t.Errorf("logs = %+v, want started then finished", got.Logs)
+	}
+
+	var changes []model.TaskStatusChange
+	if err := DB.Where("task_id = ?", task.ID).Order("created_at asc").Find(&changes).Error; err != nil {
+		t.Fatalf("load status changes: %v", err)
+	}
+	var statuses []string
+	for _, c := range changes {
+		statuses = append(statuses, c.ToStatus)
+	}
+	want := []string{model.TaskStatusTodo, model.TaskStatusInProgress, model.TaskStatusDone}
+	if len(statuses) != len(want) || statuses[0] != want[0] || statuses[1] != want[1] || statuses[2] != want[2] {
+		t.Errorf("status changes = %v, want %v", statuses, want)
+	}
+
+	if err := DeleteTask(task.ID); err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+	var logs int64
+	DB.Model(&model.TaskLog{}).Where("task_id = ?", task.ID).Count(&logs)
+	if logs != 0 {
+		t.Errorf("%d logs left after DeleteTask", logs)
+	}
+}
+
+// TestGetActiveTasksOrder checks that tasks without a deadline sort last, which
+// databases disagree on for NULLs.
+func TestGetActiveTasksOrder(t *testing.T) {
+	setupTestDB(t)
+
+	now := time.Now()
+	later := now.Add(48 * time.Hour)
+	soon := now.Add(24 * time.Hour)
+	for _, req := range []model.CreateTaskReq{
+		{Title: "no deadline", Category: "dev"},
+		{Title: "later", Category: "dev", Deadline: &later},
+		{Title: "soon", Category: "dev", Deadline: &soon},
+	} {
+		if _, err := CreateTask(req); err != nil {
+			t.Fatalf("CreateTask(%s): %v", req.Title, err)
+		}
+	}
+	done := createTestTask(t, "done", "dev")
+	if err := UpdateProgress(done.ID, model.UpdateProgressReq{LogText: "done", MarkAsDone: true}); err != nil {
+		t.Fatalf("UpdateProgress: %v", err)
+	}
+
+	tasks, err := GetActiveTasks()
+	if err != nil {
+		t.Fatalf("GetActiveTasks: %v", err)
+	}
+	var titles []string
+	for _, task := range tasks {
+		titles = append(titles, task.Title)
+	}
+	want := []string{"soon", "later", "no deadline"}
+	if len(titles) != len(want) || titles[0] != want[0] || titles[1] != want[1] || titles[2] != want[2] {
+		t.Errorf("active tasks = %v, want %v", titles, want)
+	}
+}
+
+func TestResolveTaskID(t *testing.T) {
+	setupTestDB(t)
+
+	first := createTestTask(t, "first", "dev")
+	second := createTestTask(t, "second", "dev")
+
+	for _, tt := range []struct {
+		ref  string
+		want string
+	}{
+		{"#1", first.ID},
+		{"2", second.ID},
+		{first.ID, first.ID},
+		{first.ID[:8], first.ID},
+	} {
+		got, err := ResolveTaskID(tt.ref)
+		if err != nil || got != tt.want {
+			t.Errorf("ResolveTaskID(%q) = %q, %v; want %q", tt.ref, got, err, tt.want)
+		}
+	}
+
+	for _, ref := range []string{"#3", "zzzzzzzz", "ab"} {
+		if _, err := ResolveTaskID(ref); !errors.Is(err, ErrTaskNotFound) {
+			t.Errorf("ResolveTaskID(%q) = %v, want ErrTaskNotFound", ref, err)
+		}
+	}
+}
+
+func TestResolveAmbiguousTaskID(t *testing.T) {
+	setupTestDB(t)
+
+	now := time.Now()
+	dump := &model.Dump{
+		Format:  model.DumpFormat,
+		Version: model.DumpVersion,
+		Tasks: []model.Task{
+			{ID: "abcd1234-0000-4000-8000-000000000001", Title: "one", Category: "dev", Status: model.TaskStatusTodo, CreatedAt: now, UpdatedAt: now},
+			{ID: "abcd5678-0000-4000-8000-000000000002", Title: "two", Category: "dev", Status: model.TaskStatusTodo, CreatedAt: now, UpdatedAt: now},
+		},
+	}
+	if _, err := ImportDump(dump, model.ImportModeMerge); err != nil {
+		t.Fatalf("ImportDump: %v", err)
+	}
+
+	_, err := ResolveTaskID("ABCD")
+	if !errors.Is(err, ErrAmbiguousTaskID) {
+		t.Fatalf("ResolveTaskID(abcd) = %v, want ErrAmbiguousTaskID", err)
+	}
+	for _, id := range []string{dump.Tasks[0].ID, dump.Tasks[1].ID} {
+		if !strings.Contains(err.Error(), id) {
+			t.Errorf("error %q does not list %s", err, id)
+		}
+	}
+
+	if got, err := ResolveTaskID("abcd5"); err != nil || got != dump.Tasks[1].ID {
+		t.Errorf("ResolveTaskID(abcd5) = %q, %v; want %s", got, err, dump.Tasks[1].ID)
+	}
+}
+
+func TestShortIDsAreNotReused(t *testing.T) {
+	setupTestDB(t)
+
+	createTestTask(t, "one", "dev")
+	createTestTask(t, "two", "dev")
+	last := createTestTask(t, "three", "dev")
+	if err := DeleteTask(last.ID); err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+
+	next := createTestTask(t, "four", "dev")
+	if next.ShortID != 4 {
+		t.Errorf("short id after deleting #3 = %d, want 4", next.ShortID)
+	}
+	if _, err := ResolveTaskID("#3"); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("ResolveTaskID(#3) after delete = %v, want ErrTaskNotFound", err)
+	}
+}
+
+func TestCreateTaskConcurrently(t *testing.T) {
+	setupTestDB(t)
+
+	const n = 8
+	errs := make(chan error, n)
+	ids := make(chan int, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			task, err := CreateTask(model.CreateTaskReq{Title: "concurrent", Category: "dev"})
+			if err != nil {
+				errs <- err
+				return
+			}
+			ids <- task.ShortID
+			errs <- nil
+		}()
+	}
+	for i := 0; i < n; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("CreateTask: %v", err)
+		}
+	}
+	close(ids)
+
+	seen := make(map[int]bool)
+	for id := range ids {
+		if seen[id] {
+			t.Errorf("short id %d handed out twice", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestImportReservesShortID(t *testing.T) {
+	setupTestDB(t)
+
+	now := time.Now()
+	dump := &model.Dump{
+		Format:  model.DumpFormat,
+		Version: model.DumpVersion,
+		Tasks:   []model.Task{{ID: "0f0e0d0c-0000-4000-8000-000000000001", ShortID: 10, Title: "imported", Category: "dev", Status: model.TaskStatusTodo, CreatedAt: now, UpdatedAt: now}},
+	}
+	if _, err := ImportDump(dump, model.ImportModeMerge); err != nil {
+		t.Fatalf("ImportDump: %v", err)
+	}
+
+	if task := createTestTask(t, "after import", "dev"); task.ShortID != 11 {
+		t.Errorf("short id after importing #10 = %d, want 11", task.ShortID)
+	}
+}
+
+func TestCreateTaskSkipsTakenShortID(t *testing.T) {
+	setupTestDB(t)
+
+	createTestTask(t, "one", "dev")
+	createTestTask(t, "two", "dev")
+	// A counter behind the tasks hands out ids that are already in use
+	if err := DB.Model(&Counter{}).Where("name = ?", shortIDCounter).Update("value", 0).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if task := createTestTask(t, "three", "dev"); task.ShortID != 3 {
+		t.Errorf("short id = %d, want 3", task.ShortID)
+	}
+}
+
+func TestFindWorklogsLongRange(t *testing.T) {
+	setupTestDB(t)
+
+	task := createTestTask(t, "long running", "dev")
+	now := time.Now()
+	addTestLog(t, task.ID, "two years ago", now.AddDate(-2, 0, 0))
+	addTestLog(t, task.ID, "today", now)
+
+	logs, err := FindWorklogs(now.AddDate(-2, 0, -1).Format("2006-01-02"), "", time.Local)
+	if err != nil {
+		t.Fatalf("FindWorklogs over two years: %v", err)
+	}
+	if len(logs) != 2 {
+		t.Errorf("found %d worklogs, want 2", len(logs))
+	}
+
+	if _, err := FindWorklogs("bad", "", time.Local); !errors.Is(err, ErrInvalidDateRange) {
+		t.Errorf("FindWorklogs(from=bad) = %v, want ErrInvalidDateRange", err)
+	}
+}
+
+func TestUpdateTaskLinks(t *testing.T) {
+	setupTestDB(t)
+
+	task, err := CreateTask(model.CreateTaskReq{Title: "linked", Category: "dev", Links: model.ParseLinks("https://github.com/acme/web/pull/7")})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+
+	// Requests pass through JSON on the way to a remote server
+	update := func(req model.UpdateTaskReq) *model.Task {
+		t.Helper()
+		data, err := json.Marshal(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded model.UpdateTaskReq
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		got, err := UpdateTask(task.ID, decoded)
+		if err != nil {
+			t.Fatalf("UpdateTask(%s): %v", data, err)
+		}
+		return got
+	}
+
+	if got := update(model.UpdateTaskReq{Description: "no links given"}); len(got.Links) != 1 {
+		t.Errorf("links after an update without links = %v, want them kept", got.Links)
+	}
+	if got := update(model.UpdateTaskReq{Links: &model.TaskLinks{}}); len(got.Links) != 0 {
+		t.Errorf("links after clearing = %v, want none", got.Links)
+	}
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidDateRange is returned for from / to bounds that cannot be parsed or do
+// not form a valid range.
+var ErrInvalidDateRange = errors.New("invalid date range")
+
+// maxSeriesDays caps the length of a time series to keep responses bounded.
+const maxSeriesDays = 366
+
+// dbTime converts a query bound to the process-local zone. Timestamps are written
+// to SQLite in local time and the driver compares them as text, so bounds must
+// carry the same offset as the stored values to compare correctly. PostgreSQL and
+// MySQL compare real timestamps, for which the conversion is a no-op.
+func dbTime(t time.Time) time.Time {
+	return t.Local()
+}
+
+// DayBounds returns [start, end) of the given day (YYYY-MM-DD) in loc. An empty
+// date means today in loc.
+func DayBounds(dateStr string, loc *time.Location) (time.Time, time.Time, error) {
+	var day time.Time
+	if dateStr == "" {
+		now := time.Now().In(loc)
+		day = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	} else {
+		d, err := time.ParseInLocation("2006-01-02", dateStr, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		day = d
+	}
+
+	// AddDate rather than 24h so days with a DST switch keep their real length
+	return day, day.AddDate(0, 0, 1), nil
+}
+
+// startOfWeek returns Monday 00:00 of the week containing t, in t's location.
+func startOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return day.AddDate(0, 0, -offset)
+}
+
+// parseDateRange parses YYYY-MM-DD bounds (inclusive) in loc like parseDateBounds,
+// and refuses ranges longer than maxSeriesDays.
+func parseDateRange(fromStr, toStr string, defaultDays int, loc *time.Location) (time.Time, time.Time, error) {
+	from, to, err := parseDateBounds(fromStr, toStr, defaultDays, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if to.Sub(from) > maxSeriesDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: exceeds %d days", ErrInvalidDateRange, maxSeriesDays)
+	}
+	return from, to, nil
+}
+
+// parseDateBounds parses YYYY-MM-DD bounds (inclusive) in loc, without limiting the
+// length of the range. Missing bounds default to the `defaultDays` days ending today.
+func parseDateBounds(fromStr, toStr string, defaultDays int, loc *time.Location) (time.Time, time.Time, error) {
+	to, _, err := DayBounds(toStr, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: to %q: expected YYYY-MM-DD", ErrInvalidDateRange, toStr)
+	}
+
+	from := to.AddDate(0, 0, -(defaultDays - 1))
+	if fromStr != "" {
+		f, err := time.ParseInLocation("2006-01-02", fromStr, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: from %q: expected YYYY-MM-DD", ErrInvalidDateRange, fromStr)
+		}
+		from = f
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: from (%s) is after to (%s)", ErrInvalidDateRange, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+	return from, to, nil
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("timezone %s not available: %v", name, err)
+	}
+	return loc
+}
+
+func TestDayBounds(t *testing.T) {
+	shanghai := mustLoadLocation(t, "Asia/Shanghai")
+	newYork := mustLoadLocation(t, "America/New_York")
+
+	tests := []struct {
+		name       string
+		date       string
+		loc        *time.Location
+		start, end time.Time
+	}{
+		{"utc", "2026-03-09", time.UTC, time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)},
+		{"shanghai", "2026-03-09", shanghai, time.Date(2026, 3, 8, 16, 0, 0, 0, time.UTC), time.Date(2026, 3, 9, 16, 0, 0, 0, time.UTC)},
+		// Clocks spring forward on 2026-03-08 in New York, so that day is 23 hours long
+		{"dst switch", "2026-03-08", newYork, time.Date(2026, 3, 8, 5, 0, 0, 0, time.UTC), time.Date(2026, 3, 9, 4, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := DayBounds(tt.date, tt.loc)
+			if err != nil {
+				t.Fatalf("DayBounds: %v", err)
+			}
+			if !start.Equal(tt.start) || !end.Equal(tt.end) {
+				t.Errorf("DayBounds(%s) = [%s, %s), want [%s, %s)", tt.date, start.UTC(), end.UTC(), tt.start, tt.end)
+			}
+			if start.Location() != tt.loc {
+				t.Errorf("start location = %s, want %s", start.Location(), tt.loc)
+			}
+		})
+	}
+
+	if _, _, err := DayBounds("2026/03/09", time.UTC); err == nil {
+		t.Error("DayBounds accepted a malformed date")
+	}
+}
+
+// TestSummariesAcrossMidnight logs at 23:30 and 00:30 local time in each zone and
+// checks that every log lands on its own local day.
+func TestSummariesAcrossMidnight(t *testing.T) {
+	for _, name := range []string{"Asia/Shanghai", "UTC"} {
+		t.Run(name, func(t *testing.T) {
+			loc := mustLoadLocation(t, name)
+			setupTestDB(t)
+
+			task := createTestTask(t, "straddle midnight", "dev")
+			addTestLog(t, task.ID, "late", time.Date(2026, 3, 9, 23, 30, 0, 0, loc))
+			addTestLog(t, task.ID, "early", time.Date(2026, 3, 10, 0, 30, 0, 0, loc))
+
+			for date, want := range map[string][]string{
+				"2026-03-09": {"23:30 - late"},
+				"2026-03-10": {"00:30 - early"},
+				"2026-03-11": nil,
+			} {
+				summary, err := GetDailySummary(date, loc)
+				if err != nil {
+					t.Fatalf("GetDailySummary(%s): %v", date, err)
+				}
+				var got []string
+				for _, a := range summary.Activities {
+					got = append(got, a.TodayLogs...)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("GetDailySummary(%s) logs = %q, want %q", date, got, want)
+				}
+			}
+
+			for _, tt := range []struct {
+				from, to string
+				want     []string
+			}{
+				{"2026-03-09", "2026-03-09", []string{"03-09 23:30 - late"}},
+				{"2026-03-10", "2026-03-10", []string{"03-10 00:30 - early"}},
+				{"2026-03-09", "2026-03-10", []string{"03-09 23:30 - late", "03-10 00:30 - early"}},
+			} {
+				summary, err := GetPeriodSummary(tt.from, tt.to, loc)
+				if err != nil {
+					t.Fatalf("GetPeriodSummary(%s, %s): %v", tt.from, tt.to, err)
+				}
+				var got []string
+				for _, c := range summary.Categories {
+					for _, a := range c.Tasks {
+						got = append(got, a.Logs...)
+					}
+				}
+				if summary.TotalLogs != len(tt.want) || !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("GetPeriodSummary(%s, %s) = %d logs %q, want %q", tt.from, tt.to, summary.TotalLogs, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// TestDailySummaryOtherZone reads logs written around Shanghai midnight with UTC
+// day boundaries, where both fall on the same day.
+func TestDailySummaryOtherZone(t *testing.T) {
+	shanghai := mustLoadLocation(t, "Asia/Shanghai")
+	setupTestDB(t)
+
+	task := createTestTask(t, "cross zone", "dev")
+	addTestLog(t, task.ID, "late", time.Date(2026, 3, 9, 23, 30, 0, 0, shanghai))
+	addTestLog(t, task.ID, "early", time.Date(2026, 3, 10, 0, 30, 0, 0, shanghai))
+
+	summary, err := GetDailySummary("2026-03-09", time.UTC)
+	if err != nil {
+		t.Fatalf("GetDailySummary: %v", err)
+	}
+	var got []string
+	for _, a := range summary.Activities {
+		got = append(got, a.TodayLogs...)
+	}
+	want := []string{"15:30 - late", "16:30 - early"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("logs = %q, want %q", got, want)
+	}
+}
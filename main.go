@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	_ "time/tzdata" // embed zoneinfo so --tz works in minimal images without tzdata
 
 	"github.com/yuyudeqiu/chronicle/cmd"
 )
@@ -0,0 +1,9 @@
+// Package templates embeds the default export templates into the binary.
+package templates
+
+import "embed"
+
+// FS holds the built-in Go templates used by the exporter.
+//
+//go:embed *.tmpl
+var FS embed.FS